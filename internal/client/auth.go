@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin is how long before its expiry a cached token is
+// considered expired, so requests never race against the expiration.
+const tokenRefreshMargin = 1 * time.Minute
+
+// tokenSource provides the JWT sent in the Authorization header of every
+// request.
+type tokenSource interface {
+	// Token returns a valid token, acquiring or refreshing it when needed.
+	// An empty token sends the request unauthenticated.
+	Token(ctx context.Context) (string, error)
+
+	// Invalidate discards token after the server rejected it, reporting
+	// whether a different token can be obtained by calling Token again.
+	Invalidate(token string) bool
+}
+
+// staticTokenSource always returns the same pre-minted token.
+type staticTokenSource string
+
+func (s staticTokenSource) Token(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+func (s staticTokenSource) Invalidate(token string) bool {
+	return false
+}
+
+// cachedTokenSource caches the tokens returned by acquire until shortly
+// before they expire or the server rejects them.
+type cachedTokenSource struct {
+	acquire func(ctx context.Context) (string, time.Time, error)
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (s *cachedTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && (s.expiry.IsZero() || time.Now().Add(tokenRefreshMargin).Before(s.expiry)) {
+		return s.token, nil
+	}
+
+	token, expiry, err := s.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	s.token = token
+	s.expiry = expiry
+	return s.token, nil
+}
+
+func (s *cachedTokenSource) Invalidate(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another request may have already replaced the rejected token.
+	if s.token == token {
+		s.token = ""
+		s.expiry = time.Time{}
+	}
+	return true
+}
+
+// WithLogin makes the client authenticate with the given credentials instead
+// of a pre-minted token. The client logs in on the first request, caches the
+// JWT and logs in again shortly before it expires or when the server rejects
+// it.
+func WithLogin(username string, password string) Option {
+	return func(c *dnsmasqManagerClient) error {
+		login := &loginTokenSource{
+			client:   c,
+			username: username,
+			password: password,
+		}
+		c.tokens = &cachedTokenSource{acquire: login.acquire}
+		return nil
+	}
+}
+
+type loginRequestJSON struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type loginResponseJSON struct {
+	Token string `json:"token"`
+}
+
+// loginTokenSource acquires tokens from the dnsmasq-manager login endpoint.
+type loginTokenSource struct {
+	client   *dnsmasqManagerClient
+	username string
+	password string
+}
+
+func (s *loginTokenSource) acquire(ctx context.Context) (string, time.Time, error) {
+	token, err := s.login(ctx)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("unable to log in to dnsmasq-manager as %q: %w", s.username, err)
+	}
+
+	return token, jwtExpiry(token), nil
+}
+
+func (s *loginTokenSource) login(ctx context.Context) (string, error) {
+	body, err := json.Marshal(&loginRequestJSON{Username: s.username, Password: s.password})
+	if err != nil {
+		return "", err
+	}
+
+	response_body, _, err := s.client.doRequestWithToken(
+		ctx,
+		http.MethodPost,
+		"/api/v1/auth/login",
+		body,
+		nil,
+		"",
+		http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+
+	response := loginResponseJSON{}
+	err = json.Unmarshal(response_body, &response)
+	if err != nil {
+		return "", err
+	}
+	if response.Token == "" {
+		return "", errors.New("login response did not include a token")
+	}
+
+	return response.Token, nil
+}
+
+// jwtExpiry returns the expiration time held by the "exp" claim of a JWT, or
+// the zero time when the token does not expire or cannot be decoded. The
+// token signature is not verified, that is left to the server.
+func jwtExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+
+	claims := struct {
+		Expiry *json.Number `json:"exp"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Expiry == nil {
+		return time.Time{}
+	}
+
+	seconds, err := claims.Expiry.Float64()
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(int64(seconds), 0)
+}
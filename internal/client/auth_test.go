@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testJWT returns an unsigned JWT expiring at the given time.
+func testJWT(id int32, expiry time.Time) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"%d","exp":%d}`, id, expiry.Unix())))
+	return header + "." + payload + ".signature"
+}
+
+func TestLogin(t *testing.T) {
+	tests := map[string]struct {
+		tokenLifetime time.Duration
+		rejectFirst   bool
+		expectLogins  int32
+	}{
+		"token cached between requests": {
+			tokenLifetime: time.Hour,
+			expectLogins:  1,
+		},
+		"token refreshed before expiry": {
+			tokenLifetime: 30 * time.Second,
+			expectLogins:  3,
+		},
+		"re-authenticated on unauthorized": {
+			tokenLifetime: time.Hour,
+			rejectFirst:   true,
+			expectLogins:  2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var logins atomic.Int32
+			var validToken atomic.Value
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/v1/auth/login" {
+					credentials := loginRequestJSON{}
+					if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil || credentials.Username != "admin" || credentials.Password != "secret" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+
+					login := logins.Add(1)
+					token := testJWT(login, time.Now().Add(test.tokenLifetime))
+					if !test.rejectFirst || login > 1 {
+						validToken.Store(token)
+					}
+					_ = json.NewEncoder(w).Encode(&loginResponseJSON{Token: token})
+					return
+				}
+
+				if r.Header.Get("Authorization") != fmt.Sprintf("Bearer %v", validToken.Load()) {
+					w.WriteHeader(http.StatusUnauthorized)
+					_, _ = w.Write([]byte(`{"error":"unauthorized","message":"Invalid token"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"}`))
+			}))
+			defer server.Close()
+
+			dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0), WithLogin("admin", "secret"))
+			for i := 0; i < 3; i++ {
+				if _, err := dnsmasq.ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55"); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if logins.Load() != test.expectLogins {
+				t.Errorf("expected %d logins, got %d", test.expectLogins, logins.Load())
+			}
+		})
+	}
+}
+
+func TestJWTExpiry(t *testing.T) {
+	expiry := time.Unix(1700000000, 0)
+	if got := jwtExpiry(testJWT(1, expiry)); !got.Equal(expiry) {
+		t.Errorf("expected expiry %s, got %s", expiry, got)
+	}
+
+	if got := jwtExpiry("not-a-jwt"); !got.IsZero() {
+		t.Errorf("expected zero expiry for an invalid token, got %s", got)
+	}
+}
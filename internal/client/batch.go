@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// maxBatchSize is the maximum number of changes sent in a single batch
+// request. Larger batches are split into several requests.
+const maxBatchSize = 500
+
+// StaticDhcpHostOperation identifies the change applied to a host in a batch.
+type StaticDhcpHostOperation string
+
+const (
+	OperationCreate StaticDhcpHostOperation = "create"
+	OperationUpdate StaticDhcpHostOperation = "update"
+	OperationDelete StaticDhcpHostOperation = "delete"
+)
+
+// method returns the HTTP method of the equivalent single host request.
+func (o StaticDhcpHostOperation) method() string {
+	switch o {
+	case OperationCreate:
+		return http.MethodPost
+	case OperationUpdate:
+		return http.MethodPut
+	case OperationDelete:
+		return http.MethodDelete
+	default:
+		return string(o)
+	}
+}
+
+// StaticDhcpHostChange is a single change submitted in a batch. Delete
+// operations only use the host MAC address and revision.
+type StaticDhcpHostChange struct {
+	Operation StaticDhcpHostOperation
+	Host      StaticDhcpHost
+}
+
+// StaticDhcpHostResult is the outcome of a single change of a batch. Err holds
+// the same typed errors returned by the single host operations. When Err is
+// nil, Host is always set: to the submitted host, with the revision returned
+// by the server, when the server did not return the host.
+type StaticDhcpHostResult struct {
+	Host *StaticDhcpHost
+	Err  error
+}
+
+type batchOperationJSON struct {
+	Operation StaticDhcpHostOperation `json:"op"`
+	Host      any                     `json:"host"`
+	Revision  string                  `json:"revision,omitempty"`
+}
+
+type batchRequestJSON struct {
+	Operations []batchOperationJSON `json:"operations"`
+}
+
+type batchResultJSON struct {
+	Status   int              `json:"status"`
+	Host     *json.RawMessage `json:"host,omitempty"`
+	Revision string           `json:"revision,omitempty"`
+	Error    *errorJSON       `json:"error,omitempty"`
+}
+
+type batchResponseJSON struct {
+	Results []batchResultJSON `json:"results"`
+}
+
+// BatchStaticDhcpHosts sends the changes in requests of at most maxBatchSize
+// changes. When a request fails after others were applied, the changes of the
+// failed request and of the requests not sent yet hold its error, so the
+// results of the applied changes are not lost.
+func (c *dnsmasqManagerClient) BatchStaticDhcpHosts(ctx context.Context, changes []StaticDhcpHostChange) ([]StaticDhcpHostResult, error) {
+	results := make([]StaticDhcpHostResult, 0, len(changes))
+	for start := 0; start < len(changes); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(changes))
+
+		chunkResults, err := c.batchRequest(ctx, changes[start:end])
+		if err != nil && start == 0 {
+			return nil, err
+		}
+		if err != nil {
+			for range changes[start:] {
+				results = append(results, StaticDhcpHostResult{Err: err})
+			}
+			return results, nil
+		}
+		results = append(results, chunkResults...)
+	}
+
+	return results, nil
+}
+
+func (c *dnsmasqManagerClient) batchRequest(ctx context.Context, changes []StaticDhcpHostChange) ([]StaticDhcpHostResult, error) {
+	codec := c.hostCodec()
+	request := batchRequestJSON{Operations: make([]batchOperationJSON, len(changes))}
+	for i, change := range changes {
+		host, err := codec.encode(change.Host)
+		if err != nil {
+			return nil, err
+		}
+		request.Operations[i] = batchOperationJSON{Operation: change.Operation, Host: host, Revision: change.Host.Revision}
+	}
+
+	body, err := json.Marshal(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	path := codec.routePrefix() + "/static/hosts/batch"
+	response_body, err := c.doRequest(ctx, http.MethodPost, path, body, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	response := batchResponseJSON{}
+	err = json.Unmarshal(response_body, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Results) != len(changes) {
+		return nil, fmt.Errorf("dnsmasq-manager returned %d results for a batch of %d changes", len(response.Results), len(changes))
+	}
+
+	results := make([]StaticDhcpHostResult, len(changes))
+	for i, result := range response.Results {
+		if result.Status >= 200 && result.Status < 300 {
+			// The host is optional in the results, callers rely on getting
+			// one back.
+			host := changes[i].Host
+			if result.Host != nil {
+				host, err = codec.decode(*result.Host)
+				if err != nil {
+					return nil, err
+				}
+			}
+			host.Revision = result.Revision
+			results[i].Host = &host
+			continue
+		}
+
+		apiErr := APIError{
+			StatusCode: result.Status,
+			Method:     changes[i].Operation.method(),
+			URL:        c.endpoints.url(path),
+		}
+		if result.Error != nil {
+			apiErr.ErrorCode = result.Error.Error
+			apiErr.Message = result.Error.Message
+			apiErr.Details = result.Error.Details
+		}
+		results[i].Err = newAPIError(apiErr)
+	}
+
+	return results, nil
+}
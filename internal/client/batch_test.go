@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newBatchServer returns a server applying batches to an in-memory set of
+// hosts, counting the batch requests it receives.
+func newBatchServer(t *testing.T, requests *int) *httptest.Server {
+	t.Helper()
+
+	hosts := map[string]staticDhcpHostV1JSON{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/static/hosts/batch" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		*requests++
+
+		request := struct {
+			Operations []struct {
+				Operation StaticDhcpHostOperation `json:"op"`
+				Host      staticDhcpHostV1JSON    `json:"host"`
+			} `json:"operations"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatal(err)
+		}
+
+		response := batchResponseJSON{}
+		for _, op := range request.Operations {
+			_, exists := hosts[op.Host.MacAddress]
+			switch {
+			case op.Operation == OperationCreate && exists:
+				response.Results = append(response.Results, batchResultJSON{Status: http.StatusConflict, Error: &errorJSON{Error: "conflict", Message: "Host already exists"}})
+			case op.Operation != OperationCreate && !exists:
+				response.Results = append(response.Results, batchResultJSON{Status: http.StatusNotFound, Error: &errorJSON{Error: "not_found", Message: "Host not found"}})
+			case op.Operation == OperationDelete:
+				host := hosts[op.Host.MacAddress]
+				delete(hosts, op.Host.MacAddress)
+				response.Results = append(response.Results, batchResultJSON{Status: http.StatusOK, Host: rawJSON(t, host)})
+			default:
+				host := op.Host
+				hosts[op.Host.MacAddress] = host
+				response.Results = append(response.Results, batchResultJSON{Status: http.StatusCreated, Host: rawJSON(t, host)})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(&response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// rawJSON returns the JSON encoding of value.
+func rawJSON(t *testing.T, value any) *json.RawMessage {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := json.RawMessage(data)
+	return &raw
+}
+
+func TestBatchStaticDhcpHosts(t *testing.T) {
+	var requests int
+	server := newBatchServer(t, &requests)
+	dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0))
+
+	results, err := dnsmasq.BatchStaticDhcpHosts(context.Background(), []StaticDhcpHostChange{
+		{Operation: OperationCreate, Host: StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "1.2.3.4", HostName: "example"}},
+		{Operation: OperationCreate, Host: StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "1.2.3.4", HostName: "example"}},
+		{Operation: OperationUpdate, Host: StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "1.2.3.5", HostName: "example"}},
+		{Operation: OperationDelete, Host: StaticDhcpHost{MacAddress: "00:11:22:33:44:66"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+	if results[0].Err != nil || results[0].Host == nil || results[0].Host.IPAddress != "1.2.3.4" {
+		t.Errorf("unexpected create result %+v", results[0])
+	}
+	var conflict *ConflictError
+	if !errors.As(results[1].Err, &conflict) || conflict.Method != http.MethodPost {
+		t.Errorf("expected a conflict error, got %v", results[1].Err)
+	}
+	if results[2].Err != nil || results[2].Host.IPAddress != "1.2.3.5" {
+		t.Errorf("unexpected update result %+v", results[2])
+	}
+	var notFound *NotFoundError
+	if !errors.As(results[3].Err, &notFound) || notFound.Method != http.MethodDelete {
+		t.Errorf("expected a not found error, got %v", results[3].Err)
+	}
+}
+
+func TestBatchStaticDhcpHostsSplitsLargeBatches(t *testing.T) {
+	var requests int
+	server := newBatchServer(t, &requests)
+	dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0))
+
+	changes := make([]StaticDhcpHostChange, 2*maxBatchSize+1)
+	for i := range changes {
+		changes[i] = StaticDhcpHostChange{
+			Operation: OperationCreate,
+			Host:      StaticDhcpHost{MacAddress: fmt.Sprintf("00:11:22:33:%02x:%02x", i/256, i%256)},
+		}
+	}
+
+	results, err := dnsmasq.BatchStaticDhcpHosts(context.Background(), changes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != len(changes) {
+		t.Errorf("expected %d results, got %d", len(changes), len(results))
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 batch requests, got %d", requests)
+	}
+	for i, result := range results {
+		if result.Err != nil || result.Host.MacAddress != changes[i].Host.MacAddress {
+			t.Fatalf("unexpected result %d: %+v", i, result)
+		}
+	}
+}
+
+func TestBatchStaticDhcpHostsChunkFailure(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error":"internal","message":"Internal error"}`))
+			return
+		}
+
+		request := batchRequestJSON{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatal(err)
+		}
+		response := batchResponseJSON{Results: make([]batchResultJSON, len(request.Operations))}
+		for i := range response.Results {
+			response.Results[i] = batchResultJSON{Status: http.StatusCreated, Revision: `"1"`}
+		}
+		_ = json.NewEncoder(w).Encode(&response)
+	}))
+	t.Cleanup(server.Close)
+	dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0))
+
+	changes := make([]StaticDhcpHostChange, 2*maxBatchSize+1)
+	for i := range changes {
+		changes[i] = StaticDhcpHostChange{
+			Operation: OperationCreate,
+			Host:      StaticDhcpHost{MacAddress: fmt.Sprintf("00:11:22:33:%02x:%02x", i/256, i%256)},
+		}
+	}
+
+	results, err := dnsmasq.BatchStaticDhcpHosts(context.Background(), changes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != len(changes) {
+		t.Fatalf("expected %d results, got %d", len(changes), len(results))
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 batch requests, got %d", requests)
+	}
+	for i, result := range results {
+		var serverErr *ServerError
+		switch {
+		case i < maxBatchSize && (result.Err != nil || result.Host.MacAddress != changes[i].Host.MacAddress):
+			t.Fatalf("unexpected result %d of the applied request: %+v", i, result)
+		case i >= maxBatchSize && !errors.As(result.Err, &serverErr):
+			t.Fatalf("expected a server error for change %d, got %+v", i, result)
+		}
+	}
+}
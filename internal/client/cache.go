@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// NewCachingClient returns a client serving ReadStaticDhcpHost from memory.
+// The cache is populated by listing every host on the first read and lives as
+// long as the client. Hosts written through the client are invalidated and
+// read from the server afterwards. Every other operation is forwarded to
+// inner as is. Requires FeatureListStaticHosts.
+func NewCachingClient(inner Client) Client {
+	return &cachingClient{
+		Client: inner,
+		stale:  map[string]bool{},
+	}
+}
+
+type cachingClient struct {
+	Client
+
+	mu     sync.Mutex
+	hosts  map[string]StaticDhcpHost
+	stale  map[string]bool
+	loaded bool
+}
+
+// cacheKey normalizes MAC addresses, which the server matches case
+// insensitively.
+func cacheKey(macAddress string) string {
+	return strings.ToLower(macAddress)
+}
+
+func (c *cachingClient) ReadStaticDhcpHost(ctx context.Context, macAddress string) (*StaticDhcpHost, error) {
+	key := cacheKey(macAddress)
+
+	c.mu.Lock()
+	if !c.loaded {
+		// Concurrent readers wait for the listing instead of all hitting the
+		// server. On failure, the next read tries again.
+		if err := c.loadLocked(ctx); err != nil {
+			c.mu.Unlock()
+			return c.Client.ReadStaticDhcpHost(ctx, macAddress)
+		}
+	}
+	host, cached := c.hosts[key]
+	stale := c.stale[key]
+	c.mu.Unlock()
+
+	if stale {
+		return c.Client.ReadStaticDhcpHost(ctx, macAddress)
+	}
+	if !cached {
+		return nil, &NotFoundError{APIError{
+			StatusCode: http.StatusNotFound,
+			Method:     http.MethodGet,
+			URL:        "cache://static/host?mac=" + macAddress,
+			ErrorCode:  "not_found",
+			Message:    "Static DHCP host not found in the static hosts listing",
+		}}
+	}
+
+	return &host, nil
+}
+
+func (c *cachingClient) loadLocked(ctx context.Context) error {
+	hosts, err := c.Client.ListStaticDhcpHosts(ctx, StaticDhcpHostFilter{})
+	if err != nil {
+		return err
+	}
+
+	c.hosts = make(map[string]StaticDhcpHost, len(hosts))
+	for _, host := range hosts {
+		c.hosts[cacheKey(host.MacAddress)] = host
+	}
+	c.loaded = true
+	return nil
+}
+
+func (c *cachingClient) invalidate(macAddress string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stale[cacheKey(macAddress)] = true
+}
+
+func (c *cachingClient) CreateStaticDhcpHost(ctx context.Context, host StaticDhcpHost) (*StaticDhcpHost, error) {
+	defer c.invalidate(host.MacAddress)
+	return c.Client.CreateStaticDhcpHost(ctx, host)
+}
+
+func (c *cachingClient) UpdateStaticDhcpHost(ctx context.Context, host StaticDhcpHost) (*StaticDhcpHost, error) {
+	defer c.invalidate(host.MacAddress)
+	return c.Client.UpdateStaticDhcpHost(ctx, host)
+}
+
+func (c *cachingClient) DeleteStaticDhcpHost(ctx context.Context, macAddress string, revision string) (*StaticDhcpHost, error) {
+	defer c.invalidate(macAddress)
+	return c.Client.DeleteStaticDhcpHost(ctx, macAddress, revision)
+}
+
+func (c *cachingClient) BatchStaticDhcpHosts(ctx context.Context, changes []StaticDhcpHostChange) ([]StaticDhcpHostResult, error) {
+	defer func() {
+		for _, change := range changes {
+			c.invalidate(change.Host.MacAddress)
+		}
+	}()
+	return c.Client.BatchStaticDhcpHosts(ctx, changes)
+}
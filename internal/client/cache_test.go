@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestCachingClient(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/api/v1/static/hosts":
+			_, _ = w.Write([]byte(`{"hosts":[
+				{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"},
+				{"MacAddress":"AA:BB:CC:DD:EE:FF","IPAddress":"1.2.3.5","HostName":"other"}
+			]}`))
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.6","HostName":"example"}`))
+		default:
+			_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.6","HostName":"example"}`))
+		}
+	}))
+	defer server.Close()
+
+	dnsmasq := NewCachingClient(newTestClient(t, server.URL, WithRetry(0, 0, 0)))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			host, err := dnsmasq.ReadStaticDhcpHost(ctx, "aa:bb:cc:dd:ee:ff")
+			if err != nil || host.HostName != "other" {
+				t.Errorf("unexpected read result: %+v %v", host, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var notFound *NotFoundError
+	if _, err := dnsmasq.ReadStaticDhcpHost(ctx, "00:00:00:00:00:00"); !errors.As(err, &notFound) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	if _, err := dnsmasq.UpdateStaticDhcpHost(ctx, StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "1.2.3.6", HostName: "example"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	host, err := dnsmasq.ReadStaticDhcpHost(ctx, "00:11:22:33:44:55")
+	if err != nil || host.IPAddress != "1.2.3.6" {
+		t.Errorf("expected the updated host to be read from the server, got: %+v %v", host, err)
+	}
+
+	if requests["GET /api/v1/static/hosts"] != 1 {
+		t.Errorf("expected a single list request, got %d", requests["GET /api/v1/static/hosts"])
+	}
+	if requests["GET /api/v1/static/host"] != 1 {
+		t.Errorf("expected a single host read request, got %d", requests["GET /api/v1/static/host"])
+	}
+}
@@ -1,17 +1,36 @@
 package client
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"net/http"
-	"strings"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
+// StaticDhcpHost is a static DHCP lease reservation. It is converted to the
+// payload of the API version spoken by the server when sent, see hostCodec.
 type StaticDhcpHost struct {
 	MacAddress string
 	IPAddress  string
 	HostName   string
+
+	// LeaseTime and Tags are only supported by dnsmasq-manager API version 2
+	// and later. LeaseTime uses the dnsmasq syntax, e.g. 12h or infinite.
+	LeaseTime string
+	Tags      []string
+
+	// Revision identifies the version of the host on the server, taken from
+	// the ETag response header. When set, updates and deletes only succeed
+	// if the host was not modified since, failing with a
+	// PreconditionFailedError otherwise.
+	Revision string
 }
 
 type errorJSON struct {
@@ -21,110 +40,387 @@ type errorJSON struct {
 }
 
 type Client interface {
-	CreateStaticDhcpHost(host StaticDhcpHost) (*StaticDhcpHost, error)
-	ReadStaticDhcpHost(macAddress string) (*StaticDhcpHost, error)
-	UpdateStaticDhcpHost(host StaticDhcpHost) (*StaticDhcpHost, error)
-	DeleteStaticDhcpHost(macAddress string) (*StaticDhcpHost, error)
+	CreateStaticDhcpHost(ctx context.Context, host StaticDhcpHost) (*StaticDhcpHost, error)
+	ReadStaticDhcpHost(ctx context.Context, macAddress string) (*StaticDhcpHost, error)
+	UpdateStaticDhcpHost(ctx context.Context, host StaticDhcpHost) (*StaticDhcpHost, error)
+	DeleteStaticDhcpHost(ctx context.Context, macAddress string, revision string) (*StaticDhcpHost, error)
+
+	// ListStaticDhcpHosts returns every host matching the filter, walking
+	// through all the result pages. Requires FeatureListStaticHosts.
+	ListStaticDhcpHosts(ctx context.Context, filter StaticDhcpHostFilter) ([]StaticDhcpHost, error)
+
+	// BatchStaticDhcpHosts applies many changes in as few requests as
+	// possible, returning a result per change in the same order. The error
+	// is only set when the batch itself failed, failures of individual
+	// changes are reported in their result. Requires
+	// FeatureBatchStaticHosts.
+	BatchStaticDhcpHosts(ctx context.Context, changes []StaticDhcpHostChange) ([]StaticDhcpHostResult, error)
+
+	// Discover queries the versions and features of the server, recording
+	// them for ServerInfo.
+	Discover(ctx context.Context) (*ServerInfo, error)
+
+	// ServerInfo returns the server information recorded by the last
+	// successful call to Discover, or nil when it has not been called.
+	ServerInfo() *ServerInfo
+}
+
+// Option configures optional behaviour of the client returned by New.
+type Option func(*dnsmasqManagerClient) error
+
+func New(apiUrl string, token string, opts ...Option) (Client, error) {
+	transport := newTransport()
+	c := &dnsmasqManagerClient{
+		httpClient: &http.Client{Transport: transport, Timeout: defaultRequestTimeout},
+		transport:  transport,
+		tokens:     staticTokenSource(token),
+		retry:      defaultRetryPolicy,
+	}
+
+	socketPath, prefix, isUnixSocket, err := parseUnixSocketURL(apiUrl)
+	if err != nil {
+		return nil, err
+	}
+	if isUnixSocket {
+		apiUrl = "http://" + unixSocketHost + prefix
+	}
+	base, err := parseBaseURL(apiUrl)
+	if err != nil {
+		return nil, err
+	}
+	c.endpoints = newEndpointSet(base)
+
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	if isUnixSocket {
+		if c.endpoints.len() > 1 {
+			return nil, errors.New("failover endpoints cannot be combined with a Unix socket URL")
+		}
+		c.dialUnixSocket(socketPath)
+	}
+
+	return c, nil
 }
 
-func New(apiUrl string, token string) Client {
-	return &dnsmasqManagerClient{
-		httpClient: http.DefaultClient,
-		apiUrl:     apiUrl,
-		jwtToken:   token,
+// WithUserAgent sets the User-Agent header sent with every request, instead
+// of the Go HTTP client default.
+func WithUserAgent(userAgent string) Option {
+	return func(c *dnsmasqManagerClient) error {
+		c.userAgent = userAgent
+		return nil
 	}
 }
 
 type dnsmasqManagerClient struct {
 	httpClient *http.Client
-	apiUrl     string
-	jwtToken   string
+	transport  *http.Transport
+	endpoints  *endpointSet
+	tokens     tokenSource
+	retry      retryPolicy
+	limiter    *requestLimiter
+	userAgent  string
+	info       atomic.Pointer[ServerInfo]
 }
 
-func (c *dnsmasqManagerClient) CreateStaticDhcpHost(host StaticDhcpHost) (*StaticDhcpHost, error) {
-	return c.staticDhcpHostRequestWithBody(http.MethodPost, host)
+func (c *dnsmasqManagerClient) CreateStaticDhcpHost(ctx context.Context, host StaticDhcpHost) (*StaticDhcpHost, error) {
+	return c.staticDhcpHostRequestWithBody(ctx, http.MethodPost, host)
 }
 
-func (c *dnsmasqManagerClient) ReadStaticDhcpHost(macAddress string) (*StaticDhcpHost, error) {
+func (c *dnsmasqManagerClient) ReadStaticDhcpHost(ctx context.Context, macAddress string) (*StaticDhcpHost, error) {
+	codec := c.hostCodec()
 	return c.staticDhcpHostRequest(
+		ctx,
+		codec,
 		http.MethodGet,
-		fmt.Sprintf("%s/api/v1/static/host?mac=%s", c.apiUrl, macAddress),
+		apiPath(codec.routePrefix()+"/static/host", url.Values{"mac": {macAddress}}),
 		nil,
+		"",
 		http.StatusOK)
 }
 
-func (c *dnsmasqManagerClient) UpdateStaticDhcpHost(host StaticDhcpHost) (*StaticDhcpHost, error) {
-	return c.staticDhcpHostRequestWithBody(http.MethodPut, host)
+func (c *dnsmasqManagerClient) UpdateStaticDhcpHost(ctx context.Context, host StaticDhcpHost) (*StaticDhcpHost, error) {
+	updated, err := c.staticDhcpHostRequestWithBody(ctx, http.MethodPut, host)
+	if host.Revision != "" && isOutcomeUnknown(err) {
+		return c.confirmUpdate(ctx, host, err)
+	}
+	return updated, err
 }
 
-func (c *dnsmasqManagerClient) DeleteStaticDhcpHost(macAddress string) (*StaticDhcpHost, error) {
-	return c.staticDhcpHostRequest(
+func (c *dnsmasqManagerClient) DeleteStaticDhcpHost(ctx context.Context, macAddress string, revision string) (*StaticDhcpHost, error) {
+	codec := c.hostCodec()
+	deleted, err := c.staticDhcpHostRequest(
+		ctx,
+		codec,
 		http.MethodDelete,
-		fmt.Sprintf("%s/api/v1/static/host?mac=%s", c.apiUrl, macAddress),
+		apiPath(codec.routePrefix()+"/static/host", url.Values{"mac": {macAddress}}),
 		nil,
+		revision,
 		http.StatusOK)
+	if revision != "" && isOutcomeUnknown(err) {
+		return c.confirmDelete(ctx, macAddress, err)
+	}
+	return deleted, err
 }
 
-func (c *dnsmasqManagerClient) staticDhcpHostRequestWithBody(httpMethod string, host StaticDhcpHost) (*StaticDhcpHost, error) {
-	body, err := json.Marshal(&host)
+// confirmUpdate reads the host back after a conditional update failed
+// without telling whether it was applied, as such requests are not retried.
+// It returns the host when it holds the update under a new revision, and err
+// otherwise.
+func (c *dnsmasqManagerClient) confirmUpdate(ctx context.Context, host StaticDhcpHost, err error) (*StaticDhcpHost, error) {
+	current, readErr := c.ReadStaticDhcpHost(ctx, host.MacAddress)
+	if readErr != nil || current.Revision == host.Revision || !sameHost(*current, host) {
+		return nil, err
+	}
+
+	tflog.SubsystemDebug(newLogContext(ctx), logSubsystem, "Conditional update was applied despite the failed request", map[string]interface{}{
+		"mac_address": host.MacAddress,
+		"error":       err.Error(),
+	})
+	return current, nil
+}
+
+// confirmDelete reads the host back after a conditional delete failed
+// without telling whether it was applied. It returns a host holding only the
+// MAC address when the host is gone, and err otherwise.
+func (c *dnsmasqManagerClient) confirmDelete(ctx context.Context, macAddress string, err error) (*StaticDhcpHost, error) {
+	_, readErr := c.ReadStaticDhcpHost(ctx, macAddress)
+	var notFound *NotFoundError
+	if !errors.As(readErr, &notFound) {
+		return nil, err
+	}
+
+	tflog.SubsystemDebug(newLogContext(ctx), logSubsystem, "Conditional delete was applied despite the failed request", map[string]interface{}{
+		"mac_address": macAddress,
+		"error":       err.Error(),
+	})
+	return &StaticDhcpHost{MacAddress: macAddress}, nil
+}
+
+func (c *dnsmasqManagerClient) staticDhcpHostRequestWithBody(ctx context.Context, httpMethod string, host StaticDhcpHost) (*StaticDhcpHost, error) {
+	codec := c.hostCodec()
+	payload, err := codec.encode(host)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
 
 	return c.staticDhcpHostRequest(
+		ctx,
+		codec,
 		httpMethod,
-		fmt.Sprintf("%s/api/v1/static/host", c.apiUrl),
-		strings.NewReader(string(body)),
+		codec.routePrefix()+"/static/host",
+		body,
+		host.Revision,
 		http.StatusCreated)
 }
 
-func (c *dnsmasqManagerClient) staticDhcpHostRequest(httpMethod string, url string, body io.Reader, successStatus int) (*StaticDhcpHost, error) {
-	request, err := http.NewRequest(httpMethod, url, body)
-	if err != nil {
-		return nil, err
+// staticDhcpHostRequest sends a single host request, whose response is
+// decoded by codec. A non-empty revision is sent as an If-Match precondition.
+func (c *dnsmasqManagerClient) staticDhcpHostRequest(ctx context.Context, codec hostCodec, httpMethod string, path string, body []byte, revision string, successStatus int) (*StaticDhcpHost, error) {
+	header := http.Header{}
+	if revision != "" {
+		header.Set("If-Match", revision)
 	}
 
-	response_body, err := c.doRequest(request, successStatus)
+	response_body, response_header, err := c.doRequestWithHeader(ctx, httpMethod, path, body, header, successStatus)
 	if err != nil {
 		return nil, err
 	}
 
-	host := StaticDhcpHost{}
-	err = json.Unmarshal(response_body, &host)
+	host, err := codec.decode(response_body)
 	if err != nil {
 		return nil, err
 	}
+	host.Revision = response_header.Get("ETag")
 
 	return &host, nil
 }
 
-func (c *dnsmasqManagerClient) doRequest(request *http.Request, successStatus int) ([]byte, error) {
-	if c.jwtToken != "" {
-		request.Header.Set("Authorization", "Bearer "+c.jwtToken)
+// doRequest sends an authenticated request for path, relative to the active
+// endpoint, and returns the body of a successful response.
+func (c *dnsmasqManagerClient) doRequest(ctx context.Context, httpMethod string, path string, body []byte, successStatus int) ([]byte, error) {
+	response_body, _, err := c.doRequestWithHeader(ctx, httpMethod, path, body, nil, successStatus)
+	return response_body, err
+}
+
+// doRequestWithHeader sends an authenticated request including the given
+// header and returns the body and header of a successful response. When the
+// server rejects the token and a new one can be obtained, the request is
+// re-authenticated and sent once more.
+func (c *dnsmasqManagerClient) doRequestWithHeader(ctx context.Context, httpMethod string, path string, body []byte, header http.Header, successStatus int) ([]byte, http.Header, error) {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response_body, response_header, err := c.doRequestWithToken(ctx, httpMethod, path, body, header, token, successStatus)
+
+	var unauthorized *UnauthorizedError
+	if errors.As(err, &unauthorized) && unauthorized.StatusCode == http.StatusUnauthorized && c.tokens.Invalidate(token) {
+		token, err = c.tokens.Token(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return c.doRequestWithToken(ctx, httpMethod, path, body, header, token, successStatus)
+	}
+
+	return response_body, response_header, err
+}
+
+// doRequestWithToken sends the request, retrying transient failures according
+// to the client retry policy, and returns the body and header of a successful
+// response. When the active endpoint fails and a standby endpoint is healthy,
+// the request is resent to the standby without consuming a retry.
+func (c *dnsmasqManagerClient) doRequestWithToken(ctx context.Context, httpMethod string, path string, body []byte, header http.Header, token string, successStatus int) ([]byte, http.Header, error) {
+	ctx = newLogContext(ctx)
+
+	failovers := 0
+	for attempt := 0; ; {
+		endpoint, base := c.endpoints.current()
+		url := resolveURL(base, path)
+
+		response_body, response, err := c.doAttempt(ctx, httpMethod, url, body, header, token, successStatus)
+		if err == nil {
+			return response_body, response.Header, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, nil, err
+		}
+
+		if failovers < c.endpoints.len()-1 && shouldFailover(httpMethod, header, response, err) && c.failover(ctx, endpoint) {
+			failovers++
+			continue
+		}
+
+		if attempt >= c.retry.maxRetries || !shouldRetry(httpMethod, header, response, err) {
+			return nil, nil, err
+		}
+
+		wait := c.retry.backoff(attempt, response)
+		tflog.SubsystemDebug(ctx, logSubsystem, "Retrying HTTP request", map[string]interface{}{
+			"method":  httpMethod,
+			"url":     url,
+			"attempt": attempt + 1,
+			"wait":    wait.String(),
+			"error":   err.Error(),
+		})
+		attempt++
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// doAttempt sends a single HTTP request. Besides the response body it returns
+// the (already closed) response, when one was received, so the caller can
+// inspect its status and headers.
+func (c *dnsmasqManagerClient) doAttempt(ctx context.Context, httpMethod string, url string, body []byte, header http.Header, token string, successStatus int) ([]byte, *http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, httpMethod, url, bodyReader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for key, values := range header {
+		request.Header[key] = values
+	}
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
 	}
 
 	request.Header.Set("Content-Type", "application/json")
+	if c.userAgent != "" {
+		request.Header.Set("User-Agent", c.userAgent)
+	}
+
+	tflog.SubsystemDebug(ctx, logSubsystem, "Sending HTTP request", map[string]interface{}{
+		"method": httpMethod,
+		"url":    url,
+	})
+	tflog.SubsystemTrace(ctx, logSubsystem, "HTTP request details", map[string]interface{}{
+		"method":          httpMethod,
+		"url":             url,
+		"request_headers": redactHeaders(request.Header),
+		"request_body":    redactBody(body),
+	})
+
+	release, err := c.limiter.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
 
+	start := time.Now()
 	response, err := c.httpClient.Do(request)
 	if err != nil {
-		return nil, err
+		tflog.SubsystemDebug(ctx, logSubsystem, "HTTP request failed", map[string]interface{}{
+			"method":      httpMethod,
+			"url":         url,
+			"duration_ms": time.Since(start).Milliseconds(),
+			"error":       err.Error(),
+		})
+		return nil, nil, err
 	}
 	defer response.Body.Close()
 
-	body, err := io.ReadAll(response.Body)
+	response_body, err := io.ReadAll(response.Body)
 	if err != nil {
-		return nil, err
+		return nil, response, err
 	}
 
+	tflog.SubsystemDebug(ctx, logSubsystem, "Received HTTP response", map[string]interface{}{
+		"method":      httpMethod,
+		"url":         url,
+		"status_code": response.StatusCode,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+	tflog.SubsystemTrace(ctx, logSubsystem, "HTTP response details", map[string]interface{}{
+		"method":           httpMethod,
+		"url":              url,
+		"status_code":      response.StatusCode,
+		"response_headers": redactHeaders(response.Header),
+		"response_body":    redactBody(response_body),
+	})
+
 	if response.StatusCode != successStatus {
+		apiErr := APIError{
+			StatusCode: response.StatusCode,
+			Method:     request.Method,
+			URL:        request.URL.String(),
+		}
+
 		response_error := errorJSON{}
-		err = json.Unmarshal(body, &response_error)
+		err = json.Unmarshal(response_body, &response_error)
 		if err != nil {
-			return nil, fmt.Errorf("Status: %d\nBody: %s", response.StatusCode, body)
+			apiErr.Message = string(response_body)
 		} else {
-			return nil, fmt.Errorf("%s\n\n%s", response_error.Message, response_error.Details)
+			apiErr.ErrorCode = response_error.Error
+			apiErr.Message = response_error.Message
+			apiErr.Details = response_error.Details
 		}
+
+		return nil, response, newAPIError(apiErr)
 	}
 
-	return body, err
+	return response_body, response, nil
 }
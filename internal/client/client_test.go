@@ -0,0 +1,209 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"terraform-provider-dnsmasq/internal/dnsmasqtest"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+// newTestClient returns a client for the given test server URL, failing the
+// test when it cannot be created.
+func newTestClient(t *testing.T, apiUrl string, opts ...Option) Client {
+	t.Helper()
+
+	c, err := New(apiUrl, "", opts...)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	return c
+}
+
+func TestStaticDhcpHostRevision(t *testing.T) {
+	revision := `"1"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != revision {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			_, _ = w.Write([]byte(`{"error":"precondition_failed","message":"Host was modified"}`))
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "Revision") {
+			t.Errorf("revision leaked into the request body: %s", body)
+		}
+
+		status := http.StatusOK
+		if r.Method == http.MethodPut {
+			revision = `"2"`
+			status = http.StatusCreated
+		}
+		w.Header().Set("ETag", revision)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"}`))
+	}))
+	defer server.Close()
+
+	dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0))
+	ctx := context.Background()
+
+	host, err := dnsmasq.ReadStaticDhcpHost(ctx, "00:11:22:33:44:55")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if host.Revision != `"1"` {
+		t.Errorf("expected revision %q, got %q", `"1"`, host.Revision)
+	}
+
+	updated, err := dnsmasq.UpdateStaticDhcpHost(ctx, *host)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Revision != `"2"` {
+		t.Errorf("expected revision %q, got %q", `"2"`, updated.Revision)
+	}
+
+	// The host read first is now outdated.
+	_, err = dnsmasq.DeleteStaticDhcpHost(ctx, host.MacAddress, host.Revision)
+	var preconditionFailed *PreconditionFailedError
+	if !errors.As(err, &preconditionFailed) {
+		t.Errorf("expected a precondition failed error, got %v", err)
+	}
+
+	if _, err := dnsmasq.DeleteStaticDhcpHost(ctx, updated.MacAddress, updated.Revision); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestConditionalWriteFailure(t *testing.T) {
+	tests := map[string]struct {
+		method       string
+		lostResponse bool
+		status       int
+		expectCalls  int
+		expectError  bool
+	}{
+		"update applied before a bad gateway": {
+			method:       http.MethodPut,
+			lostResponse: true,
+			status:       http.StatusBadGateway,
+			expectCalls:  1,
+		},
+		"update not applied before a bad gateway": {
+			method:      http.MethodPut,
+			status:      http.StatusBadGateway,
+			expectCalls: 1,
+			expectError: true,
+		},
+		"update refused with service unavailable": {
+			method:      http.MethodPut,
+			status:      http.StatusServiceUnavailable,
+			expectCalls: 2,
+		},
+		"delete applied before a gateway timeout": {
+			method:       http.MethodDelete,
+			lostResponse: true,
+			status:       http.StatusGatewayTimeout,
+			expectCalls:  1,
+		},
+		"delete not applied before a gateway timeout": {
+			method:      http.MethodDelete,
+			status:      http.StatusGatewayTimeout,
+			expectCalls: 1,
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := dnsmasqtest.NewServer()
+			defer server.Close()
+
+			revision := server.SetHost(dnsmasqtest.Host{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.5", HostName: "example"})
+			if test.lostResponse {
+				server.InjectLostResponse(test.method, "/api/v1/static/host", test.status, 1)
+			} else {
+				server.InjectError(test.method, "/api/v1/static/host", test.status, 1)
+			}
+
+			var output bytes.Buffer
+			ctx := tflogtest.RootLogger(context.Background(), &output)
+
+			dnsmasq := newTestClient(t, server.URL, WithRetry(2, time.Millisecond, time.Millisecond))
+			host := StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.6", HostName: "example", Revision: revision}
+			var err error
+			if test.method == http.MethodPut {
+				var updated *StaticDhcpHost
+				updated, err = dnsmasq.UpdateStaticDhcpHost(ctx, host)
+				if err == nil && (updated.IPAddress != "10.0.0.6" || updated.Revision == revision) {
+					t.Errorf("expected the updated host, got %+v", updated)
+				}
+			} else {
+				_, err = dnsmasq.DeleteStaticDhcpHost(ctx, host.MacAddress, host.Revision)
+			}
+			checkLogEntries(t, &output)
+
+			if test.expectError && err == nil {
+				t.Error("expected an error")
+			}
+			if !test.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			calls := 0
+			for _, request := range server.Requests() {
+				if request.Method == test.method {
+					calls++
+				}
+			}
+			if calls != test.expectCalls {
+				t.Errorf("expected %d %s requests, got %d", test.expectCalls, test.method, calls)
+			}
+		})
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	tests := map[string]struct {
+		opts            []Option
+		expectUserAgent string
+	}{
+		"default": {
+			expectUserAgent: "Go-http-client/1.1",
+		},
+		"custom": {
+			opts:            []Option{WithUserAgent("Terraform/1.9.0 terraform-provider-dnsmasq/0.1.0")},
+			expectUserAgent: "Terraform/1.9.0 terraform-provider-dnsmasq/0.1.0",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var userAgent string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userAgent = r.Header.Get("User-Agent")
+				_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"}`))
+			}))
+			defer server.Close()
+
+			dnsmasq := newTestClient(t, server.URL, test.opts...)
+			if _, err := dnsmasq.ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if userAgent != test.expectUserAgent {
+				t.Errorf("expected User-Agent %q, got %q", test.expectUserAgent, userAgent)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NewBatchingClient returns a client coalescing the static host writes
+// received within window into batch requests sent through inner. Each caller
+// still gets back its own result or error. Every other operation is
+// forwarded to inner as is. Requires FeatureBatchStaticHosts.
+func NewBatchingClient(inner Client, window time.Duration) Client {
+	return &batchingClient{
+		Client:  inner,
+		window:  window,
+		maxSize: maxBatchSize,
+	}
+}
+
+type batchingClient struct {
+	Client
+	window  time.Duration
+	maxSize int
+
+	mu      sync.Mutex
+	pending []*pendingChange
+	timer   *time.Timer
+}
+
+// pendingChange is a change waiting for its batch to be sent.
+type pendingChange struct {
+	ctx    context.Context
+	change StaticDhcpHostChange
+	result chan StaticDhcpHostResult
+}
+
+func (c *batchingClient) CreateStaticDhcpHost(ctx context.Context, host StaticDhcpHost) (*StaticDhcpHost, error) {
+	return c.enqueue(ctx, StaticDhcpHostChange{Operation: OperationCreate, Host: host})
+}
+
+func (c *batchingClient) UpdateStaticDhcpHost(ctx context.Context, host StaticDhcpHost) (*StaticDhcpHost, error) {
+	return c.enqueue(ctx, StaticDhcpHostChange{Operation: OperationUpdate, Host: host})
+}
+
+func (c *batchingClient) DeleteStaticDhcpHost(ctx context.Context, macAddress string, revision string) (*StaticDhcpHost, error) {
+	return c.enqueue(ctx, StaticDhcpHostChange{Operation: OperationDelete, Host: StaticDhcpHost{MacAddress: macAddress, Revision: revision}})
+}
+
+// enqueue adds the change to the next batch and waits for its result. If ctx
+// is cancelled while waiting, the change may still be applied by the batch.
+func (c *batchingClient) enqueue(ctx context.Context, change StaticDhcpHostChange) (*StaticDhcpHost, error) {
+	pending := &pendingChange{
+		ctx:    ctx,
+		change: change,
+		result: make(chan StaticDhcpHostResult, 1),
+	}
+
+	c.mu.Lock()
+	c.pending = append(c.pending, pending)
+	switch {
+	case len(c.pending) >= c.maxSize:
+		c.flushLocked()
+	case len(c.pending) == 1:
+		c.timer = time.AfterFunc(c.window, c.flush)
+	}
+	c.mu.Unlock()
+
+	select {
+	case result := <-pending.result:
+		return result.Host, result.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *batchingClient) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushLocked()
+}
+
+// flushLocked sends the pending changes as one batch. It must be called with
+// c.mu held.
+func (c *batchingClient) flushLocked() {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	if len(c.pending) == 0 {
+		return
+	}
+
+	batch := c.pending
+	c.pending = nil
+	go c.send(batch)
+}
+
+func (c *batchingClient) send(batch []*pendingChange) {
+	// The batch outlives the caller that opened it, keep its logging
+	// context but not its cancellation.
+	ctx := context.WithoutCancel(batch[0].ctx)
+
+	changes := make([]StaticDhcpHostChange, len(batch))
+	for i, pending := range batch {
+		changes[i] = pending.change
+	}
+
+	results, err := c.Client.BatchStaticDhcpHosts(ctx, changes)
+	for i, pending := range batch {
+		if err != nil {
+			pending.result <- StaticDhcpHostResult{Err: err}
+			continue
+		}
+		if results[i].Host == nil && results[i].Err == nil {
+			// Do not hand a nil host without error to the caller of a
+			// single host operation.
+			host := pending.change.Host
+			results[i].Host = &host
+		}
+		pending.result <- results[i]
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatchingClient(t *testing.T) {
+	var requests int
+	server := newBatchServer(t, &requests)
+	dnsmasq := NewBatchingClient(newTestClient(t, server.URL, WithRetry(0, 0, 0)), 50*time.Millisecond)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 20)
+	hosts := make([]*StaticDhcpHost, 20)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hosts[i], errs[i] = dnsmasq.CreateStaticDhcpHost(context.Background(), StaticDhcpHost{
+				MacAddress: fmt.Sprintf("00:11:22:33:44:%02x", i%10),
+				IPAddress:  fmt.Sprintf("10.0.0.%d", i),
+			})
+		}()
+	}
+	wg.Wait()
+
+	if requests != 1 {
+		t.Errorf("expected a single batch request, got %d", requests)
+	}
+
+	// Every MAC address was created twice, one of each pair must conflict.
+	var created, conflicts int
+	for i := range 20 {
+		var conflict *ConflictError
+		switch {
+		case errs[i] == nil && hosts[i].MacAddress == fmt.Sprintf("00:11:22:33:44:%02x", i%10):
+			created++
+		case errors.As(errs[i], &conflict):
+			conflicts++
+		default:
+			t.Errorf("unexpected result %d: %+v %v", i, hosts[i], errs[i])
+		}
+	}
+	if created != 10 || conflicts != 10 {
+		t.Errorf("expected 10 created and 10 conflicts, got %d and %d", created, conflicts)
+	}
+
+	if _, err := dnsmasq.DeleteStaticDhcpHost(context.Background(), "00:11:22:33:44:00", ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected a second batch request, got %d", requests)
+	}
+}
+
+func TestBatchingClientCancelledCaller(t *testing.T) {
+	var requests int
+	server := newBatchServer(t, &requests)
+	dnsmasq := NewBatchingClient(newTestClient(t, server.URL, WithRetry(0, 0, 0)), time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := dnsmasq.CreateStaticDhcpHost(ctx, StaticDhcpHost{MacAddress: "00:11:22:33:44:55"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline exceeded error, got %v", err)
+	}
+}
+
+func TestBatchingClientResultWithoutHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"results":[{"status":201,"revision":"\"7\""}]}`))
+	}))
+	defer server.Close()
+
+	dnsmasq := NewBatchingClient(newTestClient(t, server.URL, WithRetry(0, 0, 0)), time.Millisecond)
+	submitted := StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.5", HostName: "example"}
+	host, err := dnsmasq.CreateStaticDhcpHost(context.Background(), submitted)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	submitted.Revision = `"7"`
+	if host == nil || !reflect.DeepEqual(*host, submitted) {
+		t.Errorf("expected the submitted host %+v, got %+v", submitted, host)
+	}
+}
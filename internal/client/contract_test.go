@@ -0,0 +1,382 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"terraform-provider-dnsmasq/internal/dnsmasqtest"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// openAPIDocument describes the dnsmasq-manager API used by the client. Every
+// request sent and every response received in TestContract must conform to
+// it, so the client and the fake server cannot drift from the contract.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// contract validates HTTP exchanges against openapi.json with kin-openapi.
+type contract struct {
+	document *openapi3.T
+	router   routers.Router
+}
+
+func loadContract(t *testing.T) *contract {
+	t.Helper()
+
+	document, err := openapi3.NewLoader().LoadFromData(openAPIDocument)
+	if err != nil {
+		t.Fatalf("invalid OpenAPI document: %v", err)
+	}
+	if err := document.Validate(context.Background()); err != nil {
+		t.Fatalf("invalid OpenAPI document: %v", err)
+	}
+	router, err := gorillamux.NewRouter(document)
+	if err != nil {
+		t.Fatalf("invalid OpenAPI document: %v", err)
+	}
+	return &contract{document: document, router: router}
+}
+
+// contractOptions only checks that a bearer token is sent to the routes
+// requiring one, telling valid tokens apart is up to the server.
+var contractOptions = &openapi3filter.Options{
+	IncludeResponseStatus: true,
+	MultiError:            true,
+	AuthenticationFunc: func(ctx context.Context, input *openapi3filter.AuthenticationInput) error {
+		if !strings.HasPrefix(input.RequestValidationInput.Request.Header.Get("Authorization"), "Bearer ") {
+			return errors.New("missing bearer token")
+		}
+		return nil
+	},
+}
+
+// validateRequest returns the ways the request deviates from the contract,
+// and the input to validate its response with when it matches a route.
+func (c *contract) validateRequest(r *http.Request) (*openapi3filter.RequestValidationInput, []string) {
+	route, pathParams, err := c.router.FindRoute(r)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+
+	var violations []string
+	input := &openapi3filter.RequestValidationInput{Request: r, PathParams: pathParams, Route: route, Options: contractOptions}
+	if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+		violations = append(violations, err.Error())
+	}
+	// kin-openapi ignores query parameters missing from the document.
+	for name := range r.URL.Query() {
+		if route.Operation.Parameters.GetByInAndName(openapi3.ParameterInQuery, name) == nil {
+			violations = append(violations, fmt.Sprintf("undocumented query parameter %q", name))
+		}
+	}
+	return input, violations
+}
+
+// validateResponse returns the ways the response deviates from the contract.
+func (c *contract) validateResponse(input *openapi3filter.RequestValidationInput, response *http.Response, body []byte) []string {
+	var violations []string
+	responseInput := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 response.StatusCode,
+		Header:                 response.Header,
+		Options:                contractOptions,
+	}
+	if err := openapi3filter.ValidateResponse(input.Request.Context(), responseInput.SetBodyBytes(body)); err != nil {
+		violations = append(violations, err.Error())
+	}
+
+	// kin-openapi does not check the response headers.
+	spec := input.Route.Operation.Responses.Get(response.StatusCode)
+	if spec == nil {
+		spec = input.Route.Operation.Responses.Default()
+	}
+	if spec != nil {
+		for name, header := range spec.Value.Headers {
+			if header.Value.Required && response.Header.Get(name) == "" {
+				violations = append(violations, fmt.Sprintf("missing required header %q", name))
+			}
+		}
+	}
+	return violations
+}
+
+// contractTransport fails the test on every request or response deviating
+// from the contract, including static host requests sent to the routes of
+// another API version than the one advertised by the server.
+type contractTransport struct {
+	t        *testing.T
+	contract *contract
+	next     http.RoundTripper
+
+	mu         sync.Mutex
+	apiVersion string
+}
+
+func (ct *contractTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	var body []byte
+	if r.Body != nil {
+		body, _ = io.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	input, violations := ct.contract.validateRequest(r)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if prefix := ct.routePrefix(); strings.Contains(r.URL.Path, "/static/") && !strings.HasPrefix(r.URL.Path, prefix) {
+		violations = append(violations, fmt.Sprintf("the advertised API version %q expects the %s routes", ct.advertisedVersion(), prefix))
+	}
+	for _, violation := range violations {
+		ct.t.Errorf("%s %s: request breaks the contract: %s", r.Method, r.URL.Path, violation)
+	}
+
+	response, err := ct.next.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(responseBody))
+	if input == nil {
+		return response, nil
+	}
+	for _, violation := range ct.contract.validateResponse(input, response, responseBody) {
+		ct.t.Errorf("%s %s: %d response breaks the contract: %s", r.Method, r.URL.Path, response.StatusCode, violation)
+	}
+
+	info := serverInfoJSON{}
+	if r.URL.Path == "/api/v1/info" && response.StatusCode == http.StatusOK && json.Unmarshal(responseBody, &info) == nil {
+		ct.mu.Lock()
+		ct.apiVersion = info.APIVersion
+		ct.mu.Unlock()
+	}
+
+	return response, nil
+}
+
+func (ct *contractTransport) advertisedVersion() string {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	return ct.apiVersion
+}
+
+// routePrefix returns the prefix of the static host routes of the API version
+// advertised by the server, version 1 until the server has been queried.
+func (ct *contractTransport) routePrefix() string {
+	return fmt.Sprintf("/api/v%d/", max(apiMajorVersion(ct.advertisedVersion()), 1))
+}
+
+// newContractClient returns a client whose exchanges with the server are
+// validated against the contract.
+func newContractClient(t *testing.T, contract *contract, apiUrl string, token string, opts ...Option) Client {
+	t.Helper()
+
+	c, err := New(apiUrl, token, opts...)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	dnsmasq := c.(*dnsmasqManagerClient)
+	dnsmasq.httpClient.Transport = &contractTransport{t: t, contract: contract, next: dnsmasq.httpClient.Transport}
+	return dnsmasq
+}
+
+func TestContract(t *testing.T) {
+	contract := loadContract(t)
+
+	tests := map[string]struct {
+		apiVersion string
+		host       StaticDhcpHost
+	}{
+		"api version 1": {
+			apiVersion: "1.0",
+			host:       StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.5", HostName: "example"},
+		},
+		"api version 2": {
+			apiVersion: "2.0",
+			host:       StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.5", HostName: "example", LeaseTime: "12h", Tags: []string{"printers"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := dnsmasqtest.NewServer(dnsmasqtest.WithAPIVersion(test.apiVersion), dnsmasqtest.WithUser("terraform", "secret"))
+			defer server.Close()
+
+			ctx := context.Background()
+			dnsmasq := newContractClient(t, contract, server.URL, "", WithLogin("terraform", "secret"), WithRetry(1, time.Millisecond, time.Millisecond))
+			if _, err := dnsmasq.Discover(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			created, err := dnsmasq.CreateStaticDhcpHost(ctx, test.host)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := dnsmasq.CreateStaticDhcpHost(ctx, test.host); !errors.As(err, new(*ConflictError)) {
+				t.Errorf("expected a conflict error, got: %v", err)
+			}
+			invalid := test.host
+			invalid.MacAddress, invalid.IPAddress = "00:11:22:33:44:66", "invalid"
+			if _, err := dnsmasq.CreateStaticDhcpHost(ctx, invalid); !errors.As(err, new(*ValidationError)) {
+				t.Errorf("expected a validation error, got: %v", err)
+			}
+
+			server.InjectError(http.MethodGet, server.RoutePrefix()+"/static/host", http.StatusServiceUnavailable, 1)
+			read, err := dnsmasq.ReadStaticDhcpHost(ctx, test.host.MacAddress)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := dnsmasq.ReadStaticDhcpHost(ctx, "00:11:22:33:44:66"); !errors.As(err, new(*NotFoundError)) {
+				t.Errorf("expected a not found error, got: %v", err)
+			}
+
+			read.HostName = "renamed"
+			updated, err := dnsmasq.UpdateStaticDhcpHost(ctx, *read)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := dnsmasq.UpdateStaticDhcpHost(ctx, *created); !errors.As(err, new(*PreconditionFailedError)) {
+				t.Errorf("expected a precondition failed error, got: %v", err)
+			}
+
+			// Fill more than a page so the listing follows a page token.
+			for i := range listPageSize {
+				server.SetHost(dnsmasqtest.Host{MacAddress: fmt.Sprintf("00:11:22:33:%02x:%02x", i/256, i%256), IPAddress: "10.0.1.1", HostName: "filler"})
+			}
+			if _, err := dnsmasq.ListStaticDhcpHosts(ctx, StaticDhcpHostFilter{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := dnsmasq.ListStaticDhcpHosts(ctx, StaticDhcpHostFilter{CIDR: "10.0.0.0/24", HostNamePattern: "ren*", MacPrefix: "00:11"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			other := test.host
+			other.MacAddress = "00:11:22:33:44:77"
+			if _, err := dnsmasq.BatchStaticDhcpHosts(ctx, []StaticDhcpHostChange{
+				{Operation: OperationCreate, Host: other},
+				{Operation: OperationUpdate, Host: other},
+				{Operation: OperationDelete, Host: *created},
+				{Operation: OperationDelete, Host: StaticDhcpHost{MacAddress: "00:11:22:33:44:88"}},
+			}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, err := dnsmasq.DeleteStaticDhcpHost(ctx, test.host.MacAddress, updated.Revision); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := dnsmasq.DeleteStaticDhcpHost(ctx, test.host.MacAddress, ""); !errors.As(err, new(*NotFoundError)) {
+				t.Errorf("expected a not found error, got: %v", err)
+			}
+
+			invalidToken := newContractClient(t, contract, server.URL, "invalid", WithRetry(0, 0, 0))
+			if _, err := invalidToken.Discover(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := invalidToken.ReadStaticDhcpHost(ctx, test.host.MacAddress); !errors.As(err, new(*UnauthorizedError)) {
+				t.Errorf("expected an unauthorized error, got: %v", err)
+			}
+			wrongPassword := newContractClient(t, contract, server.URL, "", WithLogin("terraform", "wrong"), WithRetry(0, 0, 0))
+			if _, err := wrongPassword.Discover(ctx); !errors.As(err, new(*UnauthorizedError)) {
+				t.Errorf("expected an unauthorized error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestContractViolations(t *testing.T) {
+	contract := loadContract(t)
+
+	tests := map[string]struct {
+		schema           string
+		value            string
+		expectViolations []string
+	}{
+		"version 1 host": {
+			schema: "StaticHostV1",
+			value:  `{"MacAddress":"00:11:22:33:44:55","IPAddress":"10.0.0.5","HostName":"example"}`,
+		},
+		"version 2 host": {
+			schema: "StaticHostV2",
+			value:  `{"mac_address":"00:11:22:33:44:55","ip_address":"10.0.0.5","hostname":"example","tags":["printers"]}`,
+		},
+		"version 1 host sent to version 2": {
+			schema: "StaticHostV2",
+			value:  `{"MacAddress":"00:11:22:33:44:55","IPAddress":"10.0.0.5","HostName":"example"}`,
+			expectViolations: []string{
+				`property "MacAddress" is unsupported`,
+				`property "mac_address" is missing`,
+			},
+		},
+		"version 2 listed host in a version 1 page": {
+			schema:           "StaticHostPageV1",
+			value:            `{"hosts":[{"mac_address":"00:11:22:33:44:55","ip_address":"10.0.0.5","hostname":"example","revision":"1"}]}`,
+			expectViolations: []string{`property "MacAddress" is missing`},
+		},
+		"missing property": {
+			schema:           "LoginResponse",
+			value:            `{}`,
+			expectViolations: []string{`property "token" is missing`},
+		},
+		"unexpected property": {
+			schema:           "LoginResponse",
+			value:            `{"token":"abc","username":""}`,
+			expectViolations: []string{`property "username" is unsupported`},
+		},
+		"wrong types": {
+			schema: "BatchResponseV1",
+			value:  `{"results":[{"status":"201"},{"status":409.5,"error":{"error":"conflict","message":1}}]}`,
+			expectViolations: []string{
+				`Error at "/results/0/status": Field must be set to integer or not be present`,
+				`Error at "/results/1/error/message": Field must be set to string or not be present`,
+				`Error at "/results/1/status": Value must be an integer`,
+			},
+		},
+		"unknown feature": {
+			schema: "ServerInfo",
+			value:  `{"api_version":"2.0","version":"0.6.0","dnsmasq_version":"2.90","features":["dhcp_leases"]}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var value any
+			if err := json.Unmarshal([]byte(test.value), &value); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err := contract.document.Components.Schemas[test.schema].Value.VisitJSON(value, openapi3.MultiErrors())
+			if len(test.expectViolations) == 0 {
+				if err != nil {
+					t.Errorf("unexpected violations: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected violations %q", test.expectViolations)
+			}
+			for _, violation := range test.expectViolations {
+				if !strings.Contains(err.Error(), violation) {
+					t.Errorf("expected violation %q, got: %v", violation, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithTokenFile makes the client read the API token from a file. The file is
+// read again whenever it changes, so secrets rotated in place by an
+// orchestrator are picked up without restarting Terraform.
+func WithTokenFile(path string) Option {
+	return func(c *dnsmasqManagerClient) error {
+		c.tokens = &fileTokenSource{path: path}
+		return nil
+	}
+}
+
+// fileTokenSource reads the token from a file, caching it until the file
+// modification time or size change.
+type fileTokenSource struct {
+	path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+func (s *fileTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read API token file: %w", err)
+	}
+
+	if s.token != "" && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return s.token, nil
+	}
+
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read API token file: %w", err)
+	}
+
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", fmt.Errorf("API token file %q is empty", s.path)
+	}
+
+	s.token = token
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	return s.token, nil
+}
+
+func (s *fileTokenSource) Invalidate(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Force the file to be read again, it may have been rewritten within
+	// the modification time granularity of the filesystem.
+	if s.token == token {
+		s.token = ""
+	}
+	return true
+}
+
+// WithTokenCommand makes the client obtain the API token by executing an
+// external credential helper. The command must print a JSON object to its
+// standard output holding the token and, optionally, its expiry as an RFC 3339
+// timestamp:
+//
+//	{"token": "eyJhbGciOi...", "expiry": "2024-01-02T15:04:05Z"}
+//
+// When the expiry is omitted, it is taken from the token "exp" claim. The
+// token is cached and the command executed again shortly before it expires
+// or when the server rejects it.
+func WithTokenCommand(command []string) Option {
+	return func(c *dnsmasqManagerClient) error {
+		if len(command) == 0 || command[0] == "" {
+			return errors.New("the API token command must not be empty")
+		}
+
+		helper := &commandTokenSource{command: command}
+		c.tokens = &cachedTokenSource{acquire: helper.acquire}
+		return nil
+	}
+}
+
+type commandTokenJSON struct {
+	Token  string     `json:"token"`
+	Expiry *time.Time `json:"expiry,omitempty"`
+}
+
+// commandTokenSource acquires tokens from an external credential helper.
+type commandTokenSource struct {
+	command []string
+}
+
+func (s *commandTokenSource) acquire(ctx context.Context) (string, time.Time, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.command[0], s.command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", time.Time{}, fmt.Errorf("API token command %q failed: %w\n\n%s", s.command[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	output := commandTokenJSON{}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return "", time.Time{}, fmt.Errorf("API token command %q returned an invalid output: %w", s.command[0], err)
+	}
+	if output.Token == "" {
+		return "", time.Time{}, fmt.Errorf("API token command %q did not return a token", s.command[0])
+	}
+
+	if output.Expiry != nil {
+		return output.Token, *output.Expiry, nil
+	}
+	return output.Token, jwtExpiry(output.Token), nil
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTokenEchoServer returns a server rejecting requests not authenticated
+// with the token returned by validToken.
+func newTokenEchoServer(t *testing.T, validToken func() string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+validToken() {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"unauthorized","message":"Invalid token"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestTokenFile(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("first-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := "first-token"
+	server := newTokenEchoServer(t, func() string { return valid })
+	dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0), WithTokenFile(tokenFile))
+
+	if _, err := dnsmasq.ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Rotate the secret in place.
+	valid = "second-token"
+	if err := os.WriteFile(tokenFile, []byte("second-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dnsmasq.ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55"); err != nil {
+		t.Fatalf("rotated token not picked up: %v", err)
+	}
+}
+
+func TestTokenCommand(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	tests := map[string]struct {
+		command     []string
+		expectError bool
+	}{
+		"token with expiry": {
+			command: []string{"sh", "-c", `echo '{"token":"command-token","expiry":"` + expiry + `"}'`},
+		},
+		"token without expiry": {
+			command: []string{"sh", "-c", `echo '{"token":"command-token"}'`},
+		},
+		"invalid output": {
+			command:     []string{"sh", "-c", `echo command-token`},
+			expectError: true,
+		},
+		"failing command": {
+			command:     []string{"sh", "-c", `echo boom >&2; exit 1`},
+			expectError: true,
+		},
+	}
+
+	server := newTokenEchoServer(t, func() string { return "command-token" })
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0), WithTokenCommand(test.command))
+
+			_, err := dnsmasq.ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55")
+			if test.expectError && err == nil {
+				t.Error("expected an error")
+			}
+			if !test.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// APIError describes a non-success response returned by the dnsmasq-manager
+// API. Every typed error returned by the client unwraps to an *APIError, so
+// callers interested only in the status code can match it with errors.As.
+type APIError struct {
+	StatusCode int
+	Method     string
+	URL        string
+
+	// ErrorCode, Message and Details mirror the "error", "message" and
+	// "details" fields of the dnsmasq-manager error payload. When the
+	// response body is not a valid error payload, Message holds the raw body
+	// instead.
+	ErrorCode string
+	Message   string
+	Details   string
+}
+
+func (e *APIError) Error() string {
+	if e.Details == "" {
+		return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s %s: %d %s\n\n%s", e.Method, e.URL, e.StatusCode, e.Message, e.Details)
+}
+
+// NotFoundError is returned when the requested object does not exist (HTTP 404).
+type NotFoundError struct{ APIError }
+
+func (e *NotFoundError) Unwrap() error { return &e.APIError }
+
+// ConflictError is returned when the request conflicts with an existing
+// object, e.g. creating a host that is already reserved (HTTP 409).
+type ConflictError struct{ APIError }
+
+func (e *ConflictError) Unwrap() error { return &e.APIError }
+
+// PreconditionFailedError is returned when an update or delete carries a
+// revision that no longer matches the object on the server, because it was
+// modified by someone else in the meantime (HTTP 412).
+type PreconditionFailedError struct{ APIError }
+
+func (e *PreconditionFailedError) Unwrap() error { return &e.APIError }
+
+// UnauthorizedError is returned when the request is missing valid
+// credentials or they do not grant access to the object (HTTP 401 and 403).
+type UnauthorizedError struct{ APIError }
+
+func (e *UnauthorizedError) Unwrap() error { return &e.APIError }
+
+// ValidationError is returned when the API rejects the request payload
+// (HTTP 400 and 422).
+type ValidationError struct{ APIError }
+
+func (e *ValidationError) Unwrap() error { return &e.APIError }
+
+// ServerError is returned when dnsmasq-manager fails to process a valid
+// request (HTTP 5xx).
+type ServerError struct{ APIError }
+
+func (e *ServerError) Unwrap() error { return &e.APIError }
+
+// newAPIError wraps apiErr in the typed error matching its status code.
+func newAPIError(apiErr APIError) error {
+	switch {
+	case apiErr.StatusCode == http.StatusNotFound:
+		return &NotFoundError{apiErr}
+	case apiErr.StatusCode == http.StatusConflict:
+		return &ConflictError{apiErr}
+	case apiErr.StatusCode == http.StatusPreconditionFailed:
+		return &PreconditionFailedError{apiErr}
+	case apiErr.StatusCode == http.StatusUnauthorized, apiErr.StatusCode == http.StatusForbidden:
+		return &UnauthorizedError{apiErr}
+	case apiErr.StatusCode == http.StatusBadRequest, apiErr.StatusCode == http.StatusUnprocessableEntity:
+		return &ValidationError{apiErr}
+	case apiErr.StatusCode >= http.StatusInternalServerError:
+		return &ServerError{apiErr}
+	default:
+		return &apiErr
+	}
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIErrorTypes(t *testing.T) {
+	tests := map[string]struct {
+		status int
+		body   string
+		check  func(error) bool
+	}{
+		"not found": {
+			status: http.StatusNotFound,
+			body:   `{"error":"not_found","message":"Host not found","details":"no host with MAC 00:11:22:33:44:55"}`,
+			check:  func(err error) bool { var e *NotFoundError; return errors.As(err, &e) },
+		},
+		"conflict": {
+			status: http.StatusConflict,
+			body:   `{"error":"conflict","message":"Host already exists"}`,
+			check:  func(err error) bool { var e *ConflictError; return errors.As(err, &e) },
+		},
+		"unauthorized": {
+			status: http.StatusUnauthorized,
+			body:   `{"error":"unauthorized","message":"Invalid token"}`,
+			check:  func(err error) bool { var e *UnauthorizedError; return errors.As(err, &e) },
+		},
+		"validation": {
+			status: http.StatusBadRequest,
+			body:   `{"error":"bad_request","message":"Invalid MAC address"}`,
+			check:  func(err error) bool { var e *ValidationError; return errors.As(err, &e) },
+		},
+		"server error": {
+			status: http.StatusBadGateway,
+			body:   `<html>Bad Gateway</html>`,
+			check:  func(err error) bool { var e *ServerError; return errors.As(err, &e) },
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			_, err := newTestClient(t, server.URL, WithRetry(0, 0, 0)).ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !test.check(err) {
+				t.Errorf("unexpected error type %T: %v", err, err)
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error %T does not unwrap to *APIError", err)
+			}
+			if apiErr.StatusCode != test.status {
+				t.Errorf("expected status %d, got %d", test.status, apiErr.StatusCode)
+			}
+			if apiErr.Method != http.MethodGet {
+				t.Errorf("expected method GET, got %s", apiErr.Method)
+			}
+			if apiErr.Message == "" {
+				t.Error("expected a non-empty message")
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// healthCheckTimeout bounds the request used to check whether a standby
+// endpoint is alive before failing over to it.
+const healthCheckTimeout = 5 * time.Second
+
+// endpointSet holds the ordered base URLs of the dnsmasq-manager endpoints and
+// the one requests are currently sent to. The active endpoint only moves
+// forward when it fails, so the client sticks to a healthy endpoint for the
+// rest of its life.
+type endpointSet struct {
+	mu     sync.Mutex
+	urls   []*url.URL
+	active int
+}
+
+func newEndpointSet(base *url.URL) *endpointSet {
+	return &endpointSet{urls: []*url.URL{base}}
+}
+
+// current returns the index and base URL of the active endpoint.
+func (s *endpointSet) current() (int, *url.URL) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active, s.urls[s.active]
+}
+
+// url returns the URL of the API route reference, as built by apiPath, on the
+// active endpoint.
+func (s *endpointSet) url(reference string) string {
+	_, base := s.current()
+	return resolveURL(base, reference)
+}
+
+func (s *endpointSet) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.urls)
+}
+
+// WithFailover adds standby dnsmasq-manager endpoints, tried in order when the
+// active endpoint fails. Failover cannot be combined with a Unix socket URL.
+func WithFailover(standbyUrls ...string) Option {
+	return func(c *dnsmasqManagerClient) error {
+		for _, standbyUrl := range standbyUrls {
+			if IsUnixSocketURL(standbyUrl) {
+				return errors.New("failover endpoints cannot be Unix socket URLs")
+			}
+			base, err := parseBaseURL(standbyUrl)
+			if err != nil {
+				return err
+			}
+			c.endpoints.urls = append(c.endpoints.urls, base)
+		}
+		return nil
+	}
+}
+
+// shouldFailover reports whether a failed request can be sent to another
+// endpoint. Idempotent requests fail over on connection errors and on any
+// server error, while non-idempotent requests only do so when the active
+// endpoint certainly did not process them.
+func shouldFailover(httpMethod string, header http.Header, response *http.Response, err error) bool {
+	if response == nil {
+		return shouldRetry(httpMethod, header, nil, err)
+	}
+
+	if response.StatusCode < http.StatusInternalServerError {
+		return false
+	}
+
+	return isIdempotent(httpMethod, header) || response.StatusCode == http.StatusServiceUnavailable
+}
+
+// failover switches away from the endpoint with the given index to the next
+// healthy one, returning false when no other endpoint is healthy. When another
+// request already switched away from the failed endpoint it returns true
+// without checking anything, so the request is resent to the new endpoint.
+// The candidates are checked without holding the lock, so requests to the
+// active endpoint are not held up by unresponsive standbys.
+func (c *dnsmasqManagerClient) failover(ctx context.Context, failed int) bool {
+	s := c.endpoints
+	s.mu.Lock()
+	if s.active != failed {
+		s.mu.Unlock()
+		return true
+	}
+	urls := s.urls
+	s.mu.Unlock()
+
+	for i := 1; i < len(urls); i++ {
+		candidate := (failed + i) % len(urls)
+		if !c.healthCheck(ctx, urls[candidate]) {
+			tflog.SubsystemDebug(ctx, logSubsystem, "Skipping unhealthy dnsmasq-manager endpoint", map[string]interface{}{
+				"endpoint": urls[candidate].Redacted(),
+			})
+			continue
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.active == failed {
+			tflog.SubsystemWarn(ctx, logSubsystem, "Failing over to another dnsmasq-manager endpoint", map[string]interface{}{
+				"failed_endpoint": urls[failed].Redacted(),
+				"endpoint":        urls[candidate].Redacted(),
+			})
+			s.active = candidate
+		}
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active != failed
+}
+
+// healthCheck reports whether the endpoint answers the info route. Any
+// response other than a server error counts as healthy, as older servers do
+// not implement the route and the route may require authentication. Health
+// checks count against the request limits like any other request.
+func (c *dnsmasqManagerClient) healthCheck(ctx context.Context, base *url.URL) bool {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	release, err := c.limiter.acquire(ctx)
+	if err != nil {
+		return false
+	}
+	defer release()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, resolveURL(base, "/api/v1/info"), nil)
+	if err != nil {
+		return false
+	}
+	if c.userAgent != "" {
+		request.Header.Set("User-Agent", c.userAgent)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return false
+	}
+	response.Body.Close()
+
+	return response.StatusCode < http.StatusInternalServerError
+}
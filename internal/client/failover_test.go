@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFailoverServer returns a server answering every request with status, or
+// with the success status of the request when status is zero. Requests other
+// than health checks are counted in calls.
+func newFailoverServer(t *testing.T, status int, calls *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/info" {
+			calls.Add(1)
+		}
+
+		switch {
+		case status != 0:
+			w.WriteHeader(status)
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+		}
+		_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestFailover(t *testing.T) {
+	tests := map[string]struct {
+		method             string
+		primaryDown        bool
+		primaryStatus      int
+		standbyStatus      int
+		expectPrimaryCalls int32
+		expectStandbyCalls int32
+		expectError        bool
+	}{
+		"read fails over on connection error": {
+			method:             http.MethodGet,
+			primaryDown:        true,
+			expectStandbyCalls: 2,
+		},
+		"read fails over and sticks to the standby": {
+			method:             http.MethodGet,
+			primaryStatus:      http.StatusBadGateway,
+			expectPrimaryCalls: 1,
+			expectStandbyCalls: 2,
+		},
+		"read not failed over on client errors": {
+			method:             http.MethodGet,
+			primaryStatus:      http.StatusNotFound,
+			expectPrimaryCalls: 2,
+			expectError:        true,
+		},
+		"read fails when no standby is healthy": {
+			method:             http.MethodGet,
+			primaryStatus:      http.StatusBadGateway,
+			standbyStatus:      http.StatusInternalServerError,
+			expectPrimaryCalls: 2,
+			expectError:        true,
+		},
+		"create fails over on connection error": {
+			method:             http.MethodPost,
+			primaryDown:        true,
+			expectStandbyCalls: 2,
+		},
+		"create fails over on service unavailable": {
+			method:             http.MethodPost,
+			primaryStatus:      http.StatusServiceUnavailable,
+			expectPrimaryCalls: 1,
+			expectStandbyCalls: 2,
+		},
+		"create not failed over on internal server error": {
+			method:             http.MethodPost,
+			primaryStatus:      http.StatusInternalServerError,
+			expectPrimaryCalls: 2,
+			expectError:        true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var primaryCalls, standbyCalls atomic.Int32
+			primary := newFailoverServer(t, test.primaryStatus, &primaryCalls)
+			standby := newFailoverServer(t, test.standbyStatus, &standbyCalls)
+			if test.primaryDown {
+				primary.Close()
+			}
+
+			dnsmasq := newTestClient(t, primary.URL, WithRetry(0, time.Millisecond, time.Millisecond), WithFailover(standby.URL))
+
+			for i := 0; i < 2; i++ {
+				var err error
+				switch test.method {
+				case http.MethodGet:
+					_, err = dnsmasq.ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55")
+				case http.MethodPost:
+					_, err = dnsmasq.CreateStaticDhcpHost(context.Background(), StaticDhcpHost{MacAddress: "00:11:22:33:44:55"})
+				}
+
+				if test.expectError && err == nil {
+					t.Error("expected an error")
+				}
+				if !test.expectError && err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+
+			if calls := primaryCalls.Load(); calls != test.expectPrimaryCalls {
+				t.Errorf("expected %d requests to the primary, got %d", test.expectPrimaryCalls, calls)
+			}
+			if calls := standbyCalls.Load(); calls != test.expectStandbyCalls {
+				t.Errorf("expected %d requests to the standby, got %d", test.expectStandbyCalls, calls)
+			}
+		})
+	}
+}
+
+func TestFailoverHealthCheck(t *testing.T) {
+	tests := map[string]struct {
+		holdSlot     bool
+		expectActive int
+		expectResult bool
+		expectChecks int32
+	}{
+		"healthy standby": {
+			expectActive: 1,
+			expectResult: true,
+			expectChecks: 1,
+		},
+		"request limit reached": {
+			holdSlot:     true,
+			expectActive: 0,
+			expectResult: false,
+			expectChecks: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var checks atomic.Int32
+			checking := make(chan struct{}, 1)
+			unblock := make(chan struct{})
+			standby := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				checks.Add(1)
+				checking <- struct{}{}
+				<-unblock
+			}))
+			t.Cleanup(standby.Close)
+
+			c, err := New("http://localhost:6904", "", WithFailover(standby.URL), WithRateLimit(1, 0))
+			if err != nil {
+				t.Fatalf("unable to create client: %v", err)
+			}
+			dnsmasq := c.(*dnsmasqManagerClient)
+
+			ctx := context.Background()
+			if test.holdSlot {
+				release, err := dnsmasq.limiter.acquire(ctx)
+				if err != nil {
+					t.Fatalf("unable to acquire a request slot: %v", err)
+				}
+				defer release()
+
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, 20*time.Millisecond)
+				defer cancel()
+				close(unblock)
+			}
+
+			result := make(chan bool)
+			go func() { result <- dnsmasq.failover(ctx, 0) }()
+
+			if !test.holdSlot {
+				<-checking
+				// The active endpoint stays available while the standby is checked.
+				if active, _ := dnsmasq.endpoints.current(); active != 0 {
+					t.Errorf("expected the primary to stay active during the health check, got %d", active)
+				}
+				close(unblock)
+			}
+
+			if ok := <-result; ok != test.expectResult {
+				t.Errorf("expected failover to return %t, got %t", test.expectResult, ok)
+			}
+			if active, _ := dnsmasq.endpoints.current(); active != test.expectActive {
+				t.Errorf("expected endpoint %d to be active, got %d", test.expectActive, active)
+			}
+			if calls := checks.Load(); calls != test.expectChecks {
+				t.Errorf("expected %d health checks, got %d", test.expectChecks, calls)
+			}
+		})
+	}
+}
+
+func TestFailoverUnixSocket(t *testing.T) {
+	tests := map[string]struct {
+		apiUrl     string
+		standbyUrl string
+	}{
+		"socket primary": {apiUrl: "unix:///run/dmm.sock", standbyUrl: "http://localhost:6904"},
+		"socket standby": {apiUrl: "http://localhost:6904", standbyUrl: "unix:///run/dmm.sock"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(test.apiUrl, "", WithFailover(test.standbyUrl))
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
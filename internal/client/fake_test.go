@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"terraform-provider-dnsmasq/internal/dnsmasqtest"
+)
+
+func TestClientWithFakeServer(t *testing.T) {
+	tests := map[string]struct {
+		opts []dnsmasqtest.Option
+		host StaticDhcpHost
+	}{
+		"api version 1": {
+			opts: []dnsmasqtest.Option{dnsmasqtest.WithUser("terraform", "secret")},
+			host: StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.5", HostName: "example"},
+		},
+		"api version 2": {
+			opts: []dnsmasqtest.Option{dnsmasqtest.WithUser("terraform", "secret"), dnsmasqtest.WithAPIVersion("2.0")},
+			host: StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.5", HostName: "example", LeaseTime: "12h", Tags: []string{"printers"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := dnsmasqtest.NewServer(test.opts...)
+			defer server.Close()
+
+			dnsmasq := newTestClient(t, server.URL, WithLogin("terraform", "secret"), WithRetry(0, 0, 0))
+			ctx := context.Background()
+			if _, err := dnsmasq.Discover(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			created, err := dnsmasq.CreateStaticDhcpHost(ctx, test.host)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := dnsmasq.CreateStaticDhcpHost(ctx, test.host); !errors.As(err, new(*ConflictError)) {
+				t.Errorf("expected a conflict error, got: %v", err)
+			}
+
+			read, err := dnsmasq.ReadStaticDhcpHost(ctx, "00:11:22:33:44:55")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if read.Revision != created.Revision || read.LeaseTime != test.host.LeaseTime || len(read.Tags) != len(test.host.Tags) {
+				t.Errorf("expected %+v, got %+v", created, read)
+			}
+
+			// Someone else modifies the host, the revision read first is now
+			// outdated.
+			server.SetHost(dnsmasqtest.Host{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.6", HostName: "other"})
+			if _, err := dnsmasq.UpdateStaticDhcpHost(ctx, *read); !errors.As(err, new(*PreconditionFailedError)) {
+				t.Errorf("expected a precondition failed error, got: %v", err)
+			}
+
+			hosts, err := dnsmasq.ListStaticDhcpHosts(ctx, StaticDhcpHostFilter{CIDR: "10.0.0.0/24"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(hosts) != 1 || hosts[0].HostName != "other" {
+				t.Errorf("unexpected hosts: %+v", hosts)
+			}
+
+			results, err := dnsmasq.BatchStaticDhcpHosts(ctx, []StaticDhcpHostChange{
+				{Operation: OperationDelete, Host: hosts[0]},
+				{Operation: OperationDelete, Host: hosts[0]},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if results[0].Err != nil || !errors.As(results[1].Err, new(*NotFoundError)) {
+				t.Errorf("unexpected results: %+v", results)
+			}
+			if _, ok := server.Host("00:11:22:33:44:55"); ok {
+				t.Error("host not deleted")
+			}
+		})
+	}
+}
+
+func TestClientWithFakeServerFailures(t *testing.T) {
+	server := dnsmasqtest.NewServer(dnsmasqtest.WithUser("terraform", "secret"))
+	defer server.Close()
+
+	ctx := context.Background()
+	unauthenticated := newTestClient(t, server.URL, WithRetry(0, 0, 0))
+	if _, err := unauthenticated.ReadStaticDhcpHost(ctx, "00:11:22:33:44:55"); !errors.As(err, new(*UnauthorizedError)) {
+		t.Errorf("expected an unauthorized error, got: %v", err)
+	}
+
+	server.ResetRequests()
+	server.InjectError(http.MethodGet, "/api/v1/static/host", http.StatusServiceUnavailable, 2)
+
+	dnsmasq, err := New(server.URL, server.Token(), WithRetry(2, time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dnsmasq.ReadStaticDhcpHost(ctx, "00:11:22:33:44:55"); !errors.As(err, new(*NotFoundError)) {
+		t.Errorf("expected a not found error once the injected errors are exhausted, got: %v", err)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(requests))
+	}
+	if mac := requests[2].Query.Get("mac"); mac != "00:11:22:33:44:55" {
+		t.Errorf("expected the MAC address in the recorded query, got %q", mac)
+	}
+}
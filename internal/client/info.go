@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+)
+
+// Feature identifies an optional capability of the dnsmasq-manager API.
+type Feature string
+
+const (
+	// FeatureStaticHosts is the static DHCP host reservations API.
+	FeatureStaticHosts Feature = "static_hosts"
+
+	// FeatureListStaticHosts is the paginated and filtered listing of static
+	// DHCP host reservations.
+	FeatureListStaticHosts Feature = "list_static_hosts"
+
+	// FeatureBatchStaticHosts is the submission of many static DHCP host
+	// reservation changes in a single request.
+	FeatureBatchStaticHosts Feature = "batch_static_hosts"
+)
+
+// featureVersions maps each feature to the first dnsmasq-manager version
+// supporting it.
+var featureVersions = map[Feature]string{
+	FeatureStaticHosts:      "0.1.0",
+	FeatureListStaticHosts:  "0.5.0",
+	FeatureBatchStaticHosts: "0.6.0",
+}
+
+// MinimumVersion returns the first dnsmasq-manager version supporting the
+// feature.
+func (f Feature) MinimumVersion() string {
+	if version, ok := featureVersions[f]; ok {
+		return version
+	}
+	return "unknown"
+}
+
+// ServerInfo describes the dnsmasq-manager server the client talks to.
+type ServerInfo struct {
+	APIVersion     string
+	ManagerVersion string
+	DnsmasqVersion string
+	Features       []Feature
+}
+
+// Supports reports whether the server advertises the given feature.
+func (i *ServerInfo) Supports(feature Feature) bool {
+	return i != nil && slices.Contains(i.Features, feature)
+}
+
+// legacyServerInfo describes servers predating the discovery endpoint, which
+// only provide the static hosts API.
+var legacyServerInfo = ServerInfo{
+	APIVersion:     "1.0",
+	ManagerVersion: "unknown",
+	DnsmasqVersion: "unknown",
+	Features:       []Feature{FeatureStaticHosts},
+}
+
+type serverInfoJSON struct {
+	APIVersion     string    `json:"api_version"`
+	ManagerVersion string    `json:"version"`
+	DnsmasqVersion string    `json:"dnsmasq_version"`
+	Features       []Feature `json:"features"`
+}
+
+func (c *dnsmasqManagerClient) Discover(ctx context.Context) (*ServerInfo, error) {
+	response_body, err := c.doRequest(
+		ctx,
+		http.MethodGet,
+		"/api/v1/info",
+		nil,
+		http.StatusOK)
+
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		info := legacyServerInfo
+		c.info.Store(&info)
+		return &info, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := serverInfoJSON{}
+	err = json.Unmarshal(response_body, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	info := ServerInfo(response)
+	c.info.Store(&info)
+	return &info, nil
+}
+
+func (c *dnsmasqManagerClient) ServerInfo() *ServerInfo {
+	return c.info.Load()
+}
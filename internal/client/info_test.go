@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	tests := map[string]struct {
+		status         int
+		body           string
+		expectedAPI    string
+		expectedFeats  []Feature
+		missingFeature Feature
+	}{
+		"server with discovery endpoint": {
+			status:        http.StatusOK,
+			body:          `{"api_version":"1.1","version":"0.4.0","dnsmasq_version":"2.90","features":["static_hosts","something_new"]}`,
+			expectedAPI:   "1.1",
+			expectedFeats: []Feature{FeatureStaticHosts, "something_new"},
+		},
+		"legacy server": {
+			status:         http.StatusNotFound,
+			body:           `404 page not found`,
+			expectedAPI:    "1.0",
+			expectedFeats:  []Feature{FeatureStaticHosts},
+			missingFeature: "something_new",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/info" {
+					t.Errorf("unexpected request path %q", r.URL.Path)
+				}
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0))
+			if dnsmasq.ServerInfo() != nil {
+				t.Error("expected no server info before discovery")
+			}
+
+			info, err := dnsmasq.Discover(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if dnsmasq.ServerInfo() == nil || dnsmasq.ServerInfo().APIVersion != info.APIVersion {
+				t.Error("server info not recorded")
+			}
+			if info.APIVersion != test.expectedAPI {
+				t.Errorf("expected API version %q, got %q", test.expectedAPI, info.APIVersion)
+			}
+			for _, feature := range test.expectedFeats {
+				if !info.Supports(feature) {
+					t.Errorf("expected feature %q to be supported", feature)
+				}
+			}
+			if test.missingFeature != "" && info.Supports(test.missingFeature) {
+				t.Errorf("expected feature %q not to be supported", test.missingFeature)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits the requests sent by the client, and by every resource
+// and data source sharing it, to maxConcurrent requests in flight and
+// requestsPerSecond requests started per second. Zero disables the
+// corresponding limit.
+func WithRateLimit(maxConcurrent int, requestsPerSecond float64) Option {
+	return func(c *dnsmasqManagerClient) error {
+		c.limiter = newRequestLimiter(maxConcurrent, requestsPerSecond)
+		return nil
+	}
+}
+
+// maxRequestInterval bounds the interval between requests enforced by the
+// rate limit, as the interval of very low rates does not fit in a
+// time.Duration.
+const maxRequestInterval = 24 * time.Hour
+
+// requestLimiter caps the number of concurrent requests and spaces their
+// start to enforce a maximum rate.
+type requestLimiter struct {
+	slots    chan struct{}
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newRequestLimiter(maxConcurrent int, requestsPerSecond float64) *requestLimiter {
+	l := &requestLimiter{}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	if requestsPerSecond > 0 {
+		l.interval = maxRequestInterval
+		if interval := float64(time.Second) / requestsPerSecond; interval < float64(maxRequestInterval) {
+			l.interval = time.Duration(interval)
+		}
+	}
+	return l
+}
+
+// acquire waits until a request can be started, returning the function
+// releasing its concurrency slot once it completes.
+func (l *requestLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	if wait := l.reserve(); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	return release, nil
+}
+
+// reserve books the next start time allowed by the rate limit and returns how
+// long to wait for it.
+func (l *requestLimiter) reserve() time.Duration {
+	if l.interval == 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.interval)
+
+	return start.Sub(now)
+}
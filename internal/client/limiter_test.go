@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimitConcurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			observed := maxInFlight.Load()
+			if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"}`))
+	}))
+	defer server.Close()
+
+	dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0), WithRateLimit(2, 0))
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := dnsmasq.ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55"); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight.Load() > 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", maxInFlight.Load())
+	}
+}
+
+func TestRateLimitRequestsPerSecond(t *testing.T) {
+	limiter := newRequestLimiter(0, 100)
+
+	start := time.Now()
+	for range 11 {
+		release, err := limiter.acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+
+	// The first request starts immediately, the next ten 10ms apart.
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected requests to be spread over 100ms, took %s", elapsed)
+	}
+}
+
+func TestRateLimitTinyRate(t *testing.T) {
+	limiter := newRequestLimiter(0, 1e-10)
+	if limiter.interval != maxRequestInterval {
+		t.Errorf("expected an interval of %s, got %s", maxRequestInterval, limiter.interval)
+	}
+
+	release, err := limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	// The rate limit still applies to the next request.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx); err == nil {
+		t.Error("expected an error while waiting for the rate limit")
+	}
+}
+
+func TestRateLimitCancelled(t *testing.T) {
+	limiter := newRequestLimiter(1, 0)
+	release, err := limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx); err == nil {
+		t.Error("expected an error while waiting for a slot")
+	}
+}
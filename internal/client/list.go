@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// listPageSize is the number of hosts requested per page when listing.
+const listPageSize = 100
+
+// StaticDhcpHostFilter narrows the hosts returned by ListStaticDhcpHosts.
+// Filters are applied by the server and empty fields match every host.
+type StaticDhcpHostFilter struct {
+	// CIDR matches hosts whose IP address belongs to the network, e.g.
+	// 192.168.1.0/24.
+	CIDR string
+
+	// HostNamePattern matches host names against a shell glob, e.g.
+	// printer-*.
+	HostNamePattern string
+
+	// MacPrefix matches MAC addresses starting with the prefix, e.g.
+	// 00:11:22 for all the hosts of a vendor. Matching is case insensitive.
+	MacPrefix string
+}
+
+// listedRevisionJSON holds the revision included in each listed host
+// payload, alongside the fields of the host.
+type listedRevisionJSON struct {
+	Revision string `json:"revision,omitempty"`
+}
+
+type staticDhcpHostPageJSON struct {
+	Hosts         []json.RawMessage `json:"hosts"`
+	NextPageToken string            `json:"next_page_token"`
+}
+
+func (c *dnsmasqManagerClient) ListStaticDhcpHosts(ctx context.Context, filter StaticDhcpHostFilter) ([]StaticDhcpHost, error) {
+	query := url.Values{}
+	if filter.CIDR != "" {
+		query.Set("cidr", filter.CIDR)
+	}
+	if filter.HostNamePattern != "" {
+		query.Set("hostname", filter.HostNamePattern)
+	}
+	if filter.MacPrefix != "" {
+		query.Set("mac_prefix", filter.MacPrefix)
+	}
+	query.Set("page_size", strconv.Itoa(listPageSize))
+
+	codec := c.hostCodec()
+	hosts := []StaticDhcpHost{}
+	seenTokens := map[string]bool{}
+	for {
+		response_body, err := c.doRequest(
+			ctx,
+			http.MethodGet,
+			apiPath(codec.routePrefix()+"/static/hosts", query),
+			nil,
+			http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+
+		page := staticDhcpHostPageJSON{}
+		err = json.Unmarshal(response_body, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, listed := range page.Hosts {
+			host, err := codec.decode(listed)
+			if err != nil {
+				return nil, err
+			}
+
+			revision := listedRevisionJSON{}
+			if err := json.Unmarshal(listed, &revision); err != nil {
+				return nil, err
+			}
+			host.Revision = revision.Revision
+
+			hosts = append(hosts, host)
+		}
+
+		if page.NextPageToken == "" {
+			return hosts, nil
+		}
+		// Guard against servers returning the same page over and over.
+		if seenTokens[page.NextPageToken] {
+			return nil, fmt.Errorf("dnsmasq-manager returned page token %q twice while listing static hosts", page.NextPageToken)
+		}
+		seenTokens[page.NextPageToken] = true
+		query.Set("page_token", page.NextPageToken)
+	}
+}
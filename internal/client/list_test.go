@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestListStaticDhcpHosts(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/static/hosts" {
+			t.Errorf("unexpected request path %q", r.URL.Path)
+		}
+		queries = append(queries, r.URL.RawQuery)
+
+		// Serve 250 hosts in pages of page_size hosts.
+		offset, _ := strconv.Atoi(r.URL.Query().Get("page_token"))
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+		page := staticDhcpHostPageJSON{}
+		for i := offset; i < offset+pageSize && i < 250; i++ {
+			host := fmt.Sprintf(`{"MacAddress":"00:11:22:33:%02x:%02x","IPAddress":"10.0.%d.%d","HostName":"host-%d","revision":"%d"}`, i/256, i%256, i/256, i%256, i, i)
+			page.Hosts = append(page.Hosts, json.RawMessage(host))
+		}
+		if offset+pageSize < 250 {
+			page.NextPageToken = strconv.Itoa(offset + pageSize)
+		}
+		_ = json.NewEncoder(w).Encode(&page)
+	}))
+	defer server.Close()
+
+	dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0))
+	hosts, err := dnsmasq.ListStaticDhcpHosts(context.Background(), StaticDhcpHostFilter{
+		CIDR:            "10.0.0.0/16",
+		HostNamePattern: "host-*",
+		MacPrefix:       "00:11:22",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(hosts) != 250 {
+		t.Errorf("expected 250 hosts, got %d", len(hosts))
+	}
+	if hosts[42].Revision != "42" {
+		t.Errorf("expected revision 42, got %q", hosts[42].Revision)
+	}
+	if len(queries) != 3 {
+		t.Fatalf("expected 3 page requests, got %d", len(queries))
+	}
+	expected := "cidr=10.0.0.0%2F16&hostname=host-%2A&mac_prefix=00%3A11%3A22&page_size=100&page_token=200"
+	if queries[2] != expected {
+		t.Errorf("expected query %q, got %q", expected, queries[2])
+	}
+}
+
+func TestListStaticDhcpHostsRepeatedPageToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"hosts":[],"next_page_token":"same"}`))
+	}))
+	defer server.Close()
+
+	dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0))
+	if _, err := dnsmasq.ListStaticDhcpHosts(context.Background(), StaticDhcpHostFilter{}); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// logSubsystem is the tflog subsystem of the client log entries, which can be
+// filtered with the TF_LOG_PROVIDER_DNSMASQ_CLIENT environment variable.
+const logSubsystem = "dnsmasq_client"
+
+const redacted = "***"
+
+var (
+	// jwtRegexp matches JSON Web Tokens wherever they appear in a log entry.
+	jwtRegexp = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`)
+
+	// secretJSONFieldRegexp matches the value of token-like JSON fields.
+	secretJSONFieldRegexp = regexp.MustCompile(`(?i)("[a-z_]*(?:token|password|secret)[a-z_]*"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+// newLogContext returns a context logging into the client subsystem, masking
+// credentials that could leak into the log entries.
+func newLogContext(ctx context.Context) context.Context {
+	ctx = tflog.NewSubsystem(ctx, logSubsystem)
+	ctx = tflog.SubsystemMaskFieldValuesWithFieldKeys(ctx, logSubsystem, "authorization", "password", "token")
+	ctx = tflog.SubsystemMaskAllFieldValuesRegexes(ctx, logSubsystem, jwtRegexp)
+	return ctx
+}
+
+// redactBody masks the value of token-like fields of a JSON body.
+func redactBody(body []byte) string {
+	return secretJSONFieldRegexp.ReplaceAllString(string(body), `$1"`+redacted+`"`)
+}
+
+// redactHeaders formats the headers for logging, masking the credentials.
+func redactHeaders(headers http.Header) string {
+	lines := make([]string, 0, len(headers))
+	for key, values := range headers {
+		value := strings.Join(values, ", ")
+		if strings.EqualFold(key, "Authorization") || strings.EqualFold(key, "Cookie") {
+			value = redacted
+		}
+		lines = append(lines, key+": "+value)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
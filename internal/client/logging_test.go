@@ -0,0 +1,82 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestRequestLogging(t *testing.T) {
+	token := testJWT(1, time.Now().Add(time.Hour))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example","token":"` + token + `"}`))
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	dnsmasq, err := New(server.URL, token, WithRetry(0, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dnsmasq.ReadStaticDhcpHost(ctx, "00:11:22:33:44:55"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(output.String(), token) {
+		t.Error("token leaked into the log output")
+	}
+
+	var sawResponse bool
+	for _, entry := range checkLogEntries(t, &output) {
+		if entry["@message"] == "Received HTTP response" {
+			sawResponse = true
+			if entry["status_code"] != float64(http.StatusOK) {
+				t.Errorf("unexpected status code %v", entry["status_code"])
+			}
+		}
+	}
+	if !sawResponse {
+		t.Error("expected a response log entry")
+	}
+}
+
+// checkLogEntries checks that every entry of the log output was logged in
+// the client subsystem, set up by newLogContext, and returns the entries.
+func checkLogEntries(t *testing.T, output *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	entries, err := tflogtest.MultilineJSONDecode(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("expected log entries")
+	}
+
+	for _, entry := range entries {
+		if entry["@module"] != "provider."+logSubsystem {
+			t.Errorf("unexpected log module %v", entry["@module"])
+		}
+		if _, ok := entry["new_logger_warning"]; ok {
+			t.Errorf("entry %q logged without the client subsystem", entry["@message"])
+		}
+	}
+	return entries
+}
+
+func TestRedactBody(t *testing.T) {
+	body := `{"username":"admin","password":"s3cr\"et","token":"abc","HostName":"example"}`
+	expected := `{"username":"admin","password":"***","token":"***","HostName":"example"}`
+
+	if got := redactBody([]byte(body)); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
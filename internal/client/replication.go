@@ -0,0 +1,531 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Replica is one of the dnsmasq-manager servers written to by a replicated
+// client.
+type Replica struct {
+	// Endpoint identifies the replica in errors and logs, usually its API URL.
+	Endpoint string
+	Client   Client
+}
+
+// ReplicaError is the failure of an operation on a single replica.
+type ReplicaError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *ReplicaError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Endpoint, e.Err)
+}
+
+func (e *ReplicaError) Unwrap() error { return e.Err }
+
+// ReplicationError is returned by a replicated client when an operation failed
+// on some of the replicas. The operation may have succeeded on the others.
+// Every replica error can be matched with errors.As.
+type ReplicationError struct {
+	Errors   []*ReplicaError
+	Replicas int
+}
+
+func (e *ReplicationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("failed on %d of %d dnsmasq-manager replicas:\n\n%s", len(e.Errors), e.Replicas, strings.Join(messages, "\n\n"))
+}
+
+func (e *ReplicationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ReplicaDivergenceError is returned by a replicated client when a host is
+// not the same on every replica.
+type ReplicaDivergenceError struct {
+	MacAddress string
+	Endpoints  []string
+
+	// Hosts holds the host read from each of the Endpoints, nil when the
+	// replica does not have it.
+	Hosts []*StaticDhcpHost
+
+	// Host is the host of the primary with a revision combining the
+	// revision of every replica, nil when the primary does not have it.
+	Host *StaticDhcpHost
+}
+
+func (e *ReplicaDivergenceError) Error() string {
+	replicas := make([]string, len(e.Endpoints))
+	for i, endpoint := range e.Endpoints {
+		if e.Hosts[i] == nil {
+			replicas[i] = fmt.Sprintf("%s: missing", endpoint)
+			continue
+		}
+		replicas[i] = fmt.Sprintf("%s: IP address %q, hostname %q", endpoint, e.Hosts[i].IPAddress, e.Hosts[i].HostName)
+	}
+	return fmt.Sprintf("static DHCP host %s differs between dnsmasq-manager replicas:\n\n%s", e.MacAddress, strings.Join(replicas, "\n"))
+}
+
+// NewReplicatedClient returns a client keeping the static hosts of several
+// dnsmasq-manager servers identical. Writes are applied to the first replica,
+// the primary, and then to the others. A write the primary rejects fails with
+// the error of the primary and is not sent to the other replicas, except for
+// the delete of a host the primary does not have, which is still removed from
+// the others. Once the primary applied a write, the replicas left behind by a
+// partial failure are repaired: a create conflicting with an existing host
+// updates it, and an update of a missing host creates it. Reads query every
+// replica and fail with a ReplicaDivergenceError when they disagree.
+//
+// The revision of a host read or written through the client combines the
+// revision of every replica.
+func NewReplicatedClient(replicas []Replica) Client {
+	return &replicatedClient{replicas: replicas}
+}
+
+type replicatedClient struct {
+	replicas []Replica
+}
+
+func (c *replicatedClient) CreateStaticDhcpHost(ctx context.Context, host StaticDhcpHost) (*StaticDhcpHost, error) {
+	return c.write(ctx, OperationCreate, host)
+}
+
+func (c *replicatedClient) ReadStaticDhcpHost(ctx context.Context, macAddress string) (*StaticDhcpHost, error) {
+	hosts := make([]*StaticDhcpHost, len(c.replicas))
+	notFound := make([]error, len(c.replicas))
+	err := c.fanOut(func(i int, replica Client) error {
+		host, err := replica.ReadStaticDhcpHost(ctx, macAddress)
+		if errors.As(err, new(*NotFoundError)) {
+			notFound[i] = err
+			return nil
+		}
+		hosts[i] = host
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if allSet(notFound) {
+		return nil, notFound[0]
+	}
+	if err := c.checkDivergence(macAddress, hosts); err != nil {
+		return nil, err
+	}
+
+	return mergeHosts(hosts), nil
+}
+
+func (c *replicatedClient) UpdateStaticDhcpHost(ctx context.Context, host StaticDhcpHost) (*StaticDhcpHost, error) {
+	return c.write(ctx, OperationUpdate, host)
+}
+
+func (c *replicatedClient) DeleteStaticDhcpHost(ctx context.Context, macAddress string, revision string) (*StaticDhcpHost, error) {
+	return c.write(ctx, OperationDelete, StaticDhcpHost{MacAddress: macAddress, Revision: revision})
+}
+
+// ListStaticDhcpHosts lists the hosts of every replica, failing with a
+// ReplicaDivergenceError when a host is not the same on all of them.
+func (c *replicatedClient) ListStaticDhcpHosts(ctx context.Context, filter StaticDhcpHostFilter) ([]StaticDhcpHost, error) {
+	listed := make([][]StaticDhcpHost, len(c.replicas))
+	err := c.fanOut(func(i int, replica Client) error {
+		hosts, err := replica.ListStaticDhcpHosts(ctx, filter)
+		listed[i] = hosts
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var order []string
+	byMac := map[string][]*StaticDhcpHost{}
+	for i, hosts := range listed {
+		for _, host := range hosts {
+			key := cacheKey(host.MacAddress)
+			if byMac[key] == nil {
+				byMac[key] = make([]*StaticDhcpHost, len(c.replicas))
+				order = append(order, key)
+			}
+			byMac[key][i] = &host
+		}
+	}
+
+	hosts := make([]StaticDhcpHost, 0, len(order))
+	for _, key := range order {
+		if err := c.checkDivergence(key, byMac[key]); err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, *mergeHosts(byMac[key]))
+	}
+
+	return hosts, nil
+}
+
+// BatchStaticDhcpHosts sends the batch to the primary, then the changes it
+// did not reject to the other replicas. The result of a change rejected by
+// the primary holds its error, and the result of the other changes holds a
+// ReplicationError when they failed on some of the replicas.
+func (c *replicatedClient) BatchStaticDhcpHosts(ctx context.Context, changes []StaticDhcpHostChange) ([]StaticDhcpHostResult, error) {
+	primaryResults := c.batchReplica(ctx, 0, changes, false)
+
+	results := make([]StaticDhcpHostResult, len(changes))
+	var forwarded []int
+	for i, change := range changes {
+		if primaryRejected(change.Operation, primaryResults[i].Err) {
+			results[i].Err = primaryResults[i].Err
+			continue
+		}
+		forwarded = append(forwarded, i)
+	}
+	if len(forwarded) == 0 {
+		return results, nil
+	}
+
+	replicaChanges := make([]StaticDhcpHostChange, len(forwarded))
+	for j, i := range forwarded {
+		replicaChanges[j] = changes[i]
+	}
+
+	replicaResults := make([][]StaticDhcpHostResult, len(c.replicas))
+	var wg sync.WaitGroup
+	for r := 1; r < len(c.replicas); r++ {
+		wg.Go(func() {
+			replicaResults[r] = c.batchReplica(ctx, r, replicaChanges, true)
+		})
+	}
+	wg.Wait()
+
+	for j, i := range forwarded {
+		change := changes[i]
+		hosts := make([]*StaticDhcpHost, len(c.replicas))
+		errs := make([]error, len(c.replicas))
+		notFound := make([]error, len(c.replicas))
+		for r := range c.replicas {
+			result := primaryResults[i]
+			if r > 0 {
+				result = replicaResults[r][j]
+			}
+			if change.Operation == OperationDelete && errors.As(result.Err, new(*NotFoundError)) {
+				notFound[r] = result.Err
+				continue
+			}
+			hosts[r], errs[r] = result.Host, result.Err
+		}
+
+		switch {
+		case change.Operation == OperationDelete && allSet(notFound):
+			results[i].Err = notFound[0]
+		case c.replicationError(errs) != nil:
+			results[i].Err = c.replicationError(errs)
+		default:
+			results[i].Host = mergeHosts(hosts)
+		}
+	}
+
+	return results, nil
+}
+
+// batchReplica applies the changes to the replica with the given index. When
+// repair is set, the changes that failed because the replica was out of sync
+// are repaired with a second batch. A failure of the whole batch is reported
+// in the result of every change.
+func (c *replicatedClient) batchReplica(ctx context.Context, index int, changes []StaticDhcpHostChange, repair bool) []StaticDhcpHostResult {
+	replica := c.replicas[index].Client
+	replicaChanges := make([]StaticDhcpHostChange, len(changes))
+	for i, change := range changes {
+		change.Host.Revision = splitRevision(change.Host.Revision, len(c.replicas))[index]
+		replicaChanges[i] = change
+	}
+
+	results, err := replica.BatchStaticDhcpHosts(ctx, replicaChanges)
+	if err != nil {
+		results = make([]StaticDhcpHostResult, len(changes))
+		for i := range results {
+			results[i].Err = err
+		}
+		return results
+	}
+
+	var repairs []StaticDhcpHostChange
+	var repaired []int
+	for i, change := range replicaChanges {
+		if fallback := fallbackOperation(change.Operation, results[i].Err); fallback != "" && repair {
+			change.Operation = fallback
+			change.Host.Revision = ""
+			repairs = append(repairs, change)
+			repaired = append(repaired, i)
+		}
+	}
+	if len(repairs) == 0 {
+		return results
+	}
+
+	c.logRepair(ctx, index, len(repairs))
+	repairResults, err := replica.BatchStaticDhcpHosts(ctx, repairs)
+	for j, i := range repaired {
+		if err != nil {
+			results[i] = StaticDhcpHostResult{Err: err}
+			continue
+		}
+		results[i] = repairResults[j]
+	}
+
+	return results
+}
+
+// Discover discovers every replica. The returned information is the one of
+// the first replica, limited to the features supported by all of them.
+func (c *replicatedClient) Discover(ctx context.Context) (*ServerInfo, error) {
+	err := c.fanOut(func(i int, replica Client) error {
+		_, err := replica.Discover(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ServerInfo(), nil
+}
+
+func (c *replicatedClient) ServerInfo() *ServerInfo {
+	var info ServerInfo
+	for i, replica := range c.replicas {
+		replicaInfo := replica.Client.ServerInfo()
+		if replicaInfo == nil {
+			return nil
+		}
+		if i == 0 {
+			info = *replicaInfo
+			continue
+		}
+
+		var features []Feature
+		for _, feature := range info.Features {
+			if replicaInfo.Supports(feature) {
+				features = append(features, feature)
+			}
+		}
+		info.Features = features
+	}
+
+	return &info
+}
+
+// write applies a single change to the primary, then to the other replicas.
+func (c *replicatedClient) write(ctx context.Context, operation StaticDhcpHostOperation, host StaticDhcpHost) (*StaticDhcpHost, error) {
+	revisions := splitRevision(host.Revision, len(c.replicas))
+	hosts := make([]*StaticDhcpHost, len(c.replicas))
+	errs := make([]error, len(c.replicas))
+
+	primaryHost := host
+	primaryHost.Revision = revisions[0]
+	hosts[0], errs[0] = applyChange(ctx, c.replicas[0].Client, operation, primaryHost)
+	if primaryRejected(operation, errs[0]) {
+		return nil, errs[0]
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i < len(c.replicas); i++ {
+		wg.Go(func() {
+			replicaHost := host
+			replicaHost.Revision = revisions[i]
+
+			hosts[i], errs[i] = applyChange(ctx, c.replicas[i].Client, operation, replicaHost)
+			if fallback := fallbackOperation(operation, errs[i]); fallback != "" {
+				c.logRepair(ctx, i, 1)
+				replicaHost.Revision = ""
+				hosts[i], errs[i] = applyChange(ctx, c.replicas[i].Client, fallback, replicaHost)
+			}
+		})
+	}
+	wg.Wait()
+
+	if operation == OperationDelete {
+		notFound := make([]error, len(c.replicas))
+		for i, err := range errs {
+			if errors.As(err, new(*NotFoundError)) {
+				notFound[i], errs[i] = err, nil
+			}
+		}
+		if allSet(notFound) {
+			return nil, notFound[0]
+		}
+	}
+
+	if err := c.replicationError(errs); err != nil {
+		return nil, err
+	}
+
+	return mergeHosts(hosts), nil
+}
+
+// fanOut calls fn concurrently for every replica, returning a
+// ReplicationError holding the replicas for which it failed.
+func (c *replicatedClient) fanOut(fn func(i int, replica Client) error) error {
+	errs := make([]error, len(c.replicas))
+	var wg sync.WaitGroup
+	for i, replica := range c.replicas {
+		wg.Go(func() {
+			errs[i] = fn(i, replica.Client)
+		})
+	}
+	wg.Wait()
+
+	return c.replicationError(errs)
+}
+
+// replicationError returns a ReplicationError holding the non-nil errors,
+// indexed by replica, or nil when there are none.
+func (c *replicatedClient) replicationError(errs []error) error {
+	replicationErr := &ReplicationError{Replicas: len(c.replicas)}
+	for i, err := range errs {
+		if err != nil {
+			replicationErr.Errors = append(replicationErr.Errors, &ReplicaError{Endpoint: c.replicas[i].Endpoint, Err: err})
+		}
+	}
+
+	if len(replicationErr.Errors) == 0 {
+		return nil
+	}
+	return replicationErr
+}
+
+// checkDivergence returns a ReplicaDivergenceError unless every replica holds
+// the same host.
+func (c *replicatedClient) checkDivergence(macAddress string, hosts []*StaticDhcpHost) error {
+	for _, host := range hosts {
+		if host == nil || !sameHost(*host, *hosts[0]) {
+			endpoints := make([]string, len(c.replicas))
+			for i, replica := range c.replicas {
+				endpoints[i] = replica.Endpoint
+			}
+			divergence := &ReplicaDivergenceError{MacAddress: macAddress, Endpoints: endpoints, Hosts: hosts}
+			if hosts[0] != nil {
+				divergence.Host = mergeHosts(hosts)
+			}
+			return divergence
+		}
+	}
+
+	return nil
+}
+
+func (c *replicatedClient) logRepair(ctx context.Context, index int, changes int) {
+	tflog.SubsystemWarn(newLogContext(ctx), logSubsystem, "Repairing out of sync dnsmasq-manager replica", map[string]interface{}{
+		"endpoint": c.replicas[index].Endpoint,
+		"changes":  changes,
+	})
+}
+
+// sameHost reports whether a and b describe the same reservation, whatever
+// their revision.
+func sameHost(a StaticDhcpHost, b StaticDhcpHost) bool {
+	return strings.EqualFold(a.MacAddress, b.MacAddress) &&
+		a.IPAddress == b.IPAddress &&
+		a.HostName == b.HostName &&
+		a.LeaseTime == b.LeaseTime &&
+		slices.Equal(a.Tags, b.Tags)
+}
+
+// applyChange applies a single change to a replica.
+func applyChange(ctx context.Context, replica Client, operation StaticDhcpHostOperation, host StaticDhcpHost) (*StaticDhcpHost, error) {
+	switch operation {
+	case OperationCreate:
+		return replica.CreateStaticDhcpHost(ctx, host)
+	case OperationUpdate:
+		return replica.UpdateStaticDhcpHost(ctx, host)
+	default:
+		return replica.DeleteStaticDhcpHost(ctx, host.MacAddress, host.Revision)
+	}
+}
+
+// fallbackOperation returns the operation bringing a replica in line after
+// operation failed with err because the replica was out of sync with the
+// primary, or an empty operation when the failure is final.
+func fallbackOperation(operation StaticDhcpHostOperation, err error) StaticDhcpHostOperation {
+	switch {
+	case operation == OperationCreate && errors.As(err, new(*ConflictError)):
+		return OperationUpdate
+	case operation == OperationUpdate && errors.As(err, new(*NotFoundError)):
+		return OperationCreate
+	default:
+		return ""
+	}
+}
+
+// primaryRejected reports whether the primary failed to apply operation with
+// err, in which case the operation is not sent to the other replicas. A host
+// missing from the primary is still deleted from the others.
+func primaryRejected(operation StaticDhcpHostOperation, err error) bool {
+	return err != nil && (operation != OperationDelete || !errors.As(err, new(*NotFoundError)))
+}
+
+// mergeHosts returns the first host written to or read from the replicas with
+// a revision combining the revision of every replica, or nil when there is
+// none.
+func mergeHosts(hosts []*StaticDhcpHost) *StaticDhcpHost {
+	var merged *StaticDhcpHost
+	revisions := make([]string, len(hosts))
+	for i, host := range hosts {
+		if host == nil {
+			continue
+		}
+		if merged == nil {
+			copied := *host
+			merged = &copied
+		}
+		revisions[i] = host.Revision
+	}
+
+	if merged != nil {
+		merged.Revision = joinRevisions(revisions)
+	}
+	return merged
+}
+
+// joinRevisions combines the revisions of the replicas, separated by spaces
+// which cannot appear in an ETag.
+func joinRevisions(revisions []string) string {
+	for _, revision := range revisions {
+		if revision != "" {
+			return strings.Join(revisions, " ")
+		}
+	}
+	return ""
+}
+
+// splitRevision returns the revision of each replica combined by
+// joinRevisions. A revision combined for a different set of replicas is
+// ignored, the changes are then sent without precondition.
+func splitRevision(revision string, replicas int) []string {
+	revisions := strings.Split(revision, " ")
+	if len(revisions) != replicas {
+		return make([]string, replicas)
+	}
+	return revisions
+}
+
+func allSet(errs []error) bool {
+	for _, err := range errs {
+		if err == nil {
+			return false
+		}
+	}
+	return true
+}
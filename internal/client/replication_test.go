@@ -0,0 +1,307 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"terraform-provider-dnsmasq/internal/dnsmasqtest"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+// newReplicaServer starts a fake dnsmasq-manager holding the hosts, and
+// returns it with the revision of each host.
+func newReplicaServer(t *testing.T, hosts ...dnsmasqtest.Host) (*dnsmasqtest.Server, []string) {
+	server := dnsmasqtest.NewServer()
+	t.Cleanup(server.Close)
+
+	revisions := make([]string, len(hosts))
+	for i, host := range hosts {
+		revisions[i] = server.SetHost(host)
+	}
+	return server, revisions
+}
+
+func newReplicatedTestClient(t *testing.T, servers ...*dnsmasqtest.Server) Client {
+	replicas := make([]Replica, len(servers))
+	for i, server := range servers {
+		replicas[i] = Replica{Endpoint: server.URL, Client: newTestClient(t, server.URL, WithRetry(0, time.Millisecond, time.Millisecond))}
+	}
+	return NewReplicatedClient(replicas)
+}
+
+// checkReplicaHost checks that the server holds the expected host under the
+// MAC address, or nothing when expected is nil.
+func checkReplicaHost(t *testing.T, server *dnsmasqtest.Server, macAddress string, expected *dnsmasqtest.Host) {
+	t.Helper()
+
+	stored, ok := server.Host(macAddress)
+	switch {
+	case expected == nil && ok:
+		t.Errorf("expected no host on %s, got %+v", server.URL, stored)
+	case expected != nil && !reflect.DeepEqual(stored, *expected):
+		t.Errorf("expected %+v on %s, got %+v", *expected, server.URL, stored)
+	}
+}
+
+func TestReplicatedClientWrite(t *testing.T) {
+	host := dnsmasqtest.Host{MacAddress: "00:11:22:33:44:55", IPAddress: "1.2.3.4", HostName: "example"}
+	stale := dnsmasqtest.Host{MacAddress: "00:11:22:33:44:55", IPAddress: "1.2.3.5", HostName: "stale"}
+
+	tests := map[string]struct {
+		operation      StaticDhcpHostOperation
+		first          *dnsmasqtest.Host
+		second         *dnsmasqtest.Host
+		staleRevision  bool
+		expectRevision string
+		expectDeleted  bool
+		expectError    any
+
+		// expectPrimaryError is set when the error of the primary is
+		// returned unchanged, without writing to the other replica.
+		expectPrimaryError bool
+	}{
+		"create on every replica": {
+			operation:      OperationCreate,
+			expectRevision: `"1" "1"`,
+		},
+		"create updates a replica already holding the host": {
+			operation:      OperationCreate,
+			second:         &stale,
+			expectRevision: `"1" "2"`,
+		},
+		"update creates the host on a replica missing it": {
+			operation:      OperationUpdate,
+			first:          &stale,
+			expectRevision: `"2" "1"`,
+		},
+		"create conflicting with the primary": {
+			operation:          OperationCreate,
+			first:              &stale,
+			expectError:        new(*ConflictError),
+			expectPrimaryError: true,
+		},
+		"update of a host missing from the primary": {
+			operation:          OperationUpdate,
+			second:             &stale,
+			expectError:        new(*NotFoundError),
+			expectPrimaryError: true,
+		},
+		"update rejected by the primary": {
+			operation:          OperationUpdate,
+			first:              &stale,
+			second:             &stale,
+			staleRevision:      true,
+			expectError:        new(*PreconditionFailedError),
+			expectPrimaryError: true,
+		},
+		"delete rejected by the primary": {
+			operation:          OperationDelete,
+			first:              &stale,
+			second:             &stale,
+			staleRevision:      true,
+			expectError:        new(*PreconditionFailedError),
+			expectPrimaryError: true,
+		},
+		"delete from a replica missing the host": {
+			operation:     OperationDelete,
+			first:         &stale,
+			expectDeleted: true,
+		},
+		"delete from no replica": {
+			operation:   OperationDelete,
+			expectError: new(*NotFoundError),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			servers := make([]*dnsmasqtest.Server, 2)
+			revisions := make([]string, 2)
+			for i, stored := range []*dnsmasqtest.Host{test.first, test.second} {
+				if stored == nil {
+					servers[i], _ = newReplicaServer(t)
+					continue
+				}
+				var storedRevisions []string
+				servers[i], storedRevisions = newReplicaServer(t, *stored)
+				revisions[i] = storedRevisions[0]
+			}
+			if test.staleRevision {
+				revisions[0] = `"0"`
+			}
+			dnsmasq := newReplicatedTestClient(t, servers...)
+
+			written := StaticDhcpHost{MacAddress: host.MacAddress, IPAddress: host.IPAddress, HostName: host.HostName, Revision: joinRevisions(revisions)}
+
+			var output bytes.Buffer
+			ctx := tflogtest.RootLogger(context.Background(), &output)
+
+			var result *StaticDhcpHost
+			var err error
+			switch test.operation {
+			case OperationCreate:
+				result, err = dnsmasq.CreateStaticDhcpHost(ctx, written)
+			case OperationUpdate:
+				result, err = dnsmasq.UpdateStaticDhcpHost(ctx, written)
+			case OperationDelete:
+				result, err = dnsmasq.DeleteStaticDhcpHost(ctx, written.MacAddress, written.Revision)
+			}
+			checkLogEntries(t, &output)
+
+			if test.expectError != nil {
+				if !errors.As(err, test.expectError) {
+					t.Errorf("expected a %T, got: %v", test.expectError, err)
+				}
+				if test.expectPrimaryError {
+					if errors.As(err, new(*ReplicationError)) {
+						t.Errorf("expected the error of the primary, got: %v", err)
+					}
+					checkReplicaHost(t, servers[0], host.MacAddress, test.first)
+					checkReplicaHost(t, servers[1], host.MacAddress, test.second)
+					if requests := servers[1].Requests(); len(requests) != 0 {
+						t.Errorf("expected no request to the replica, got %d", len(requests))
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !test.expectDeleted && result.Revision != test.expectRevision {
+				t.Errorf("expected revision %q, got %q", test.expectRevision, result.Revision)
+			}
+
+			for _, server := range servers {
+				if test.expectDeleted {
+					checkReplicaHost(t, server, host.MacAddress, nil)
+					continue
+				}
+				checkReplicaHost(t, server, host.MacAddress, &host)
+			}
+		})
+	}
+}
+
+func TestReplicatedClientBatch(t *testing.T) {
+	conflicting := dnsmasqtest.Host{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.5", HostName: "existing"}
+	stale := dnsmasqtest.Host{MacAddress: "00:11:22:33:44:66", IPAddress: "10.0.0.9", HostName: "stale"}
+	modified := dnsmasqtest.Host{MacAddress: "00:11:22:33:44:77", IPAddress: "10.0.0.8", HostName: "modified"}
+
+	primary, _ := newReplicaServer(t, conflicting, modified)
+	replica, replicaRevisions := newReplicaServer(t, stale, modified)
+	dnsmasq := newReplicatedTestClient(t, primary, replica)
+	results, err := dnsmasq.BatchStaticDhcpHosts(context.Background(), []StaticDhcpHostChange{
+		{Operation: OperationCreate, Host: StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.6", HostName: "conflicting"}},
+		{Operation: OperationCreate, Host: StaticDhcpHost{MacAddress: "00:11:22:33:44:66", IPAddress: "10.0.0.7", HostName: "repaired"}},
+		{Operation: OperationUpdate, Host: StaticDhcpHost{MacAddress: "00:11:22:33:44:77", IPAddress: "10.0.0.7", HostName: "stale", Revision: `"0" ` + replicaRevisions[1]}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The change rejected by the primary fails with its error and is not
+	// sent to the replica.
+	if !errors.As(results[0].Err, new(*ConflictError)) || errors.As(results[0].Err, new(*ReplicationError)) {
+		t.Errorf("expected the conflict of the primary, got: %v", results[0].Err)
+	}
+	if _, ok := replica.Host("00:11:22:33:44:55"); ok {
+		t.Error("change rejected by the primary written to the replica")
+	}
+	if !errors.As(results[2].Err, new(*PreconditionFailedError)) || errors.As(results[2].Err, new(*ReplicationError)) {
+		t.Errorf("expected the precondition failure of the primary, got: %v", results[2].Err)
+	}
+	checkReplicaHost(t, replica, "00:11:22:33:44:77", &modified)
+
+	// The change applied by the primary repairs the replica.
+	if results[1].Err != nil {
+		t.Errorf("unexpected error: %v", results[1].Err)
+	}
+	if host, _ := replica.Host("00:11:22:33:44:66"); host.HostName != "repaired" {
+		t.Errorf("replica not repaired, got %+v", host)
+	}
+}
+
+func TestReplicatedClientRead(t *testing.T) {
+	host := dnsmasqtest.Host{MacAddress: "00:11:22:33:44:55", IPAddress: "1.2.3.4", HostName: "example"}
+	diverging := dnsmasqtest.Host{MacAddress: "00:11:22:33:44:55", IPAddress: "1.2.3.5", HostName: "example"}
+
+	tests := map[string]struct {
+		first       []dnsmasqtest.Host
+		second      []dnsmasqtest.Host
+		expectError any
+
+		// expectPrimaryHost is the host of the primary held by the
+		// ReplicaDivergenceError.
+		expectPrimaryHost *StaticDhcpHost
+	}{
+		"identical replicas": {
+			first:  []dnsmasqtest.Host{host},
+			second: []dnsmasqtest.Host{host},
+		},
+		"diverging replicas": {
+			first:             []dnsmasqtest.Host{host},
+			second:            []dnsmasqtest.Host{diverging},
+			expectError:       new(*ReplicaDivergenceError),
+			expectPrimaryHost: &StaticDhcpHost{MacAddress: host.MacAddress, IPAddress: host.IPAddress, HostName: host.HostName, Revision: `"1" "1"`},
+		},
+		"host missing from a replica": {
+			second:      []dnsmasqtest.Host{host},
+			expectError: new(*ReplicaDivergenceError),
+		},
+		"host missing from every replica": {
+			expectError: new(*NotFoundError),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			first, _ := newReplicaServer(t, test.first...)
+			second, _ := newReplicaServer(t, test.second...)
+			dnsmasq := newReplicatedTestClient(t, first, second)
+
+			read, err := dnsmasq.ReadStaticDhcpHost(context.Background(), host.MacAddress)
+			if test.expectError != nil {
+				if !errors.As(err, test.expectError) {
+					t.Errorf("expected a %T, got: %v", test.expectError, err)
+				}
+				var divergence *ReplicaDivergenceError
+				if errors.As(err, &divergence) && !reflect.DeepEqual(divergence.Host, test.expectPrimaryHost) {
+					t.Errorf("expected the primary host %+v, got %+v", test.expectPrimaryHost, divergence.Host)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if read.IPAddress != host.IPAddress || read.Revision != `"1" "1"` {
+				t.Errorf("unexpected host: %+v", read)
+			}
+		})
+	}
+}
+
+func TestReplicatedClientReplicaFailure(t *testing.T) {
+	host := StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "1.2.3.4", HostName: "example"}
+	available, _ := newReplicaServer(t)
+	unavailable, _ := newReplicaServer(t)
+	unavailable.Close()
+
+	dnsmasq := newReplicatedTestClient(t, available, unavailable)
+	_, err := dnsmasq.CreateStaticDhcpHost(context.Background(), host)
+
+	var replicationErr *ReplicationError
+	if !errors.As(err, &replicationErr) {
+		t.Fatalf("expected a ReplicationError, got: %v", err)
+	}
+	if len(replicationErr.Errors) != 1 || replicationErr.Errors[0].Endpoint != unavailable.URL {
+		t.Errorf("expected a single error for %s, got: %v", unavailable.URL, err)
+	}
+	if _, ok := available.Host(host.MacAddress); !ok {
+		t.Error("host not created on the available replica")
+	}
+}
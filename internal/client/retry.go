@@ -0,0 +1,150 @@
+package client
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// retryPolicy controls how transient failures are retried by doRequest.
+type retryPolicy struct {
+	maxRetries int
+	waitMin    time.Duration
+	waitMax    time.Duration
+}
+
+// Default retry policy of the client returned by New.
+const (
+	DefaultMaxRetries   = 4
+	DefaultRetryWaitMin = 1 * time.Second
+	DefaultRetryWaitMax = 30 * time.Second
+)
+
+var defaultRetryPolicy = retryPolicy{
+	maxRetries: DefaultMaxRetries,
+	waitMin:    DefaultRetryWaitMin,
+	waitMax:    DefaultRetryWaitMax,
+}
+
+// WithRetry overrides the default retry policy. Failed requests are retried up
+// to maxRetries times, waiting an exponentially growing and jittered interval
+// bounded by waitMin and waitMax between attempts, unless the server asks for
+// a specific delay through the Retry-After header, which is capped to
+// waitMax.
+func WithRetry(maxRetries int, waitMin time.Duration, waitMax time.Duration) Option {
+	return func(c *dnsmasqManagerClient) error {
+		c.retry = retryPolicy{
+			maxRetries: maxRetries,
+			waitMin:    waitMin,
+			waitMax:    waitMax,
+		}
+		return nil
+	}
+}
+
+// backoff returns how long to wait before retrying after the given attempt.
+func (p retryPolicy) backoff(attempt int, response *http.Response) time.Duration {
+	if response != nil {
+		if wait, ok := parseRetryAfter(response.Header.Get("Retry-After")); ok {
+			// Do not let a single response stall the apply for as long as
+			// the server asks.
+			return min(wait, p.waitMax)
+		}
+	}
+
+	wait := p.waitMax
+	if attempt < 32 {
+		if exp := p.waitMin << attempt; exp > 0 && exp < p.waitMax {
+			wait = exp
+		}
+	}
+	if wait <= 0 {
+		return 0
+	}
+
+	// Jitter the wait between half and the full interval so clients that
+	// failed together do not retry in lockstep.
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// parseRetryAfter parses a Retry-After header holding either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
+
+// shouldRetry reports whether a failed request can be safely retried.
+// Idempotent requests are retried on any connection error and on the
+// responses returned while dnsmasq-manager reloads. Non-idempotent requests
+// are only retried when the server certainly did not process them: when the
+// connection could not be established, or when the server explicitly refused
+// the request with 429 or 503.
+func shouldRetry(httpMethod string, header http.Header, response *http.Response, err error) bool {
+	if response == nil {
+		return isIdempotent(httpMethod, header) || isDialError(err)
+	}
+
+	switch response.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(httpMethod, header)
+	default:
+		return false
+	}
+}
+
+// isIdempotent reports whether sending the request again has no other effect
+// than sending it once. Conditional requests are not: once applied, the
+// revision they carry in If-Match is outdated and the replay fails with 412.
+func isIdempotent(httpMethod string, header http.Header) bool {
+	if header.Get("If-Match") != "" {
+		return false
+	}
+
+	switch httpMethod {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isDialError reports whether err happened while establishing the
+// connection, before anything was sent to the server.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isOutcomeUnknown reports whether a request that failed with err, without
+// being retried, may have been processed by the server anyway.
+func isOutcomeUnknown(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusBadGateway || apiErr.StatusCode == http.StatusGatewayTimeout
+	}
+	return err != nil && !isDialError(err)
+}
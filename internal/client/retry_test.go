@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	tests := map[string]struct {
+		method      string
+		statuses    []int
+		maxRetries  int
+		expectCalls int32
+		expectError bool
+	}{
+		"read retried until success": {
+			method:      http.MethodGet,
+			statuses:    []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			maxRetries:  4,
+			expectCalls: 3,
+		},
+		"read gives up after max retries": {
+			method:      http.MethodGet,
+			statuses:    []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			maxRetries:  2,
+			expectCalls: 3,
+			expectError: true,
+		},
+		"read not retried on client errors": {
+			method:      http.MethodGet,
+			statuses:    []int{http.StatusNotFound, http.StatusOK},
+			maxRetries:  4,
+			expectCalls: 1,
+			expectError: true,
+		},
+		"create not retried on bad gateway": {
+			method:      http.MethodPost,
+			statuses:    []int{http.StatusBadGateway, http.StatusCreated},
+			maxRetries:  4,
+			expectCalls: 1,
+			expectError: true,
+		},
+		"create retried on service unavailable": {
+			method:      http.MethodPost,
+			statuses:    []int{http.StatusServiceUnavailable, http.StatusCreated},
+			maxRetries:  4,
+			expectCalls: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := calls.Add(1)
+				status := test.statuses[call-1]
+				if status == http.StatusServiceUnavailable {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"}`))
+			}))
+			defer server.Close()
+
+			dnsmasq := newTestClient(t, server.URL, WithRetry(test.maxRetries, time.Millisecond, 5*time.Millisecond))
+
+			var err error
+			switch test.method {
+			case http.MethodGet:
+				_, err = dnsmasq.ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55")
+			case http.MethodPost:
+				_, err = dnsmasq.CreateStaticDhcpHost(context.Background(), StaticDhcpHost{MacAddress: "00:11:22:33:44:55"})
+			}
+
+			if test.expectError && err == nil {
+				t.Error("expected an error")
+			}
+			if !test.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if calls.Load() != test.expectCalls {
+				t.Errorf("expected %d calls, got %d", test.expectCalls, calls.Load())
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := retryPolicy{maxRetries: 10, waitMin: 100 * time.Millisecond, waitMax: time.Second}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		wait := policy.backoff(attempt, nil)
+		if wait < policy.waitMin/2 || wait > policy.waitMax {
+			t.Errorf("attempt %d: wait %s out of bounds", attempt, wait)
+		}
+	}
+
+	response := &http.Response{Header: http.Header{"Retry-After": []string{"7"}}}
+	if wait := defaultRetryPolicy.backoff(0, response); wait != 7*time.Second {
+		t.Errorf("expected Retry-After to be honoured, got %s", wait)
+	}
+	if wait := policy.backoff(0, response); wait != policy.waitMax {
+		t.Errorf("expected Retry-After to be capped to %s, got %s", policy.waitMax, wait)
+	}
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// defaultRequestTimeout bounds the time taken by a single HTTP request,
+// including reading the response body.
+const defaultRequestTimeout = 1 * time.Minute
+
+// newTransport returns the dedicated transport owned by each client. It
+// mirrors the settings of http.DefaultTransport so it can be customized
+// without affecting other users of the default transport.
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           newDialer(30 * time.Second).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+func newDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+	}
+}
+
+// TransportConfig describes the connection settings of the client transport.
+// Zero values keep the defaults.
+type TransportConfig struct {
+	// RequestTimeout bounds the time taken by a single HTTP request,
+	// including connection, redirects and reading the response body.
+	RequestTimeout time.Duration
+
+	// DialTimeout bounds the time taken to establish a connection.
+	DialTimeout time.Duration
+
+	// TLSHandshakeTimeout bounds the time taken by the TLS handshake.
+	TLSHandshakeTimeout time.Duration
+
+	// Proxy is the URL of the proxy used for all requests, except for the
+	// hosts excluded by the NO_PROXY environment variable. When empty, the
+	// proxy is taken from the HTTP_PROXY and HTTPS_PROXY environment
+	// variables.
+	Proxy string
+
+	// MaxIdleConns and MaxIdleConnsPerHost limit the number of idle
+	// connections kept open for reuse, and IdleConnTimeout how long they are
+	// kept.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+}
+
+// WithTransport configures the timeouts, proxy and connection pooling of the
+// client transport.
+func WithTransport(config TransportConfig) Option {
+	return func(c *dnsmasqManagerClient) error {
+		if config.RequestTimeout > 0 {
+			c.httpClient.Timeout = config.RequestTimeout
+		}
+		if config.DialTimeout > 0 {
+			c.transport.DialContext = newDialer(config.DialTimeout).DialContext
+		}
+		if config.TLSHandshakeTimeout > 0 {
+			c.transport.TLSHandshakeTimeout = config.TLSHandshakeTimeout
+		}
+		if config.Proxy != "" {
+			proxy, err := url.Parse(config.Proxy)
+			if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+				return fmt.Errorf("invalid proxy URL %q", config.Proxy)
+			}
+
+			proxyConfig := httpproxy.FromEnvironment()
+			proxyConfig.HTTPProxy = config.Proxy
+			proxyConfig.HTTPSProxy = config.Proxy
+			proxyFunc := proxyConfig.ProxyFunc()
+			c.transport.Proxy = func(request *http.Request) (*url.URL, error) {
+				return proxyFunc(request.URL)
+			}
+		}
+		if config.MaxIdleConns > 0 {
+			c.transport.MaxIdleConns = config.MaxIdleConns
+		}
+		if config.MaxIdleConnsPerHost > 0 {
+			c.transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+		}
+		if config.IdleConnTimeout > 0 {
+			c.transport.IdleConnTimeout = config.IdleConnTimeout
+		}
+		return nil
+	}
+}
+
+// TLSConfig describes how the client establishes TLS connections with
+// dnsmasq-manager.
+type TLSConfig struct {
+	// CACertPEM holds additional PEM encoded CA certificates trusted
+	// besides the system certificate pool.
+	CACertPEM []byte
+
+	// ClientCertPEM and ClientKeyPEM hold the PEM encoded certificate and
+	// private key presented to servers requiring mutual TLS.
+	ClientCertPEM []byte
+	ClientKeyPEM  []byte
+
+	// ServerName overrides the host name used to verify the server
+	// certificate.
+	ServerName string
+
+	// InsecureSkipVerify disables the verification of the server
+	// certificate. It should only be used for testing.
+	InsecureSkipVerify bool
+}
+
+// WithTLS configures the client transport with the given TLS settings.
+func WithTLS(config TLSConfig) Option {
+	return func(c *dnsmasqManagerClient) error {
+		tlsConfig, err := config.build()
+		if err != nil {
+			return err
+		}
+
+		c.transport.TLSClientConfig = tlsConfig
+		return nil
+	}
+}
+
+func (config TLSConfig) build() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if len(config.CACertPEM) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(config.CACertPEM) {
+			return nil, errors.New("no valid PEM encoded certificate found in the CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(config.ClientCertPEM) > 0 || len(config.ClientKeyPEM) > 0 {
+		if len(config.ClientCertPEM) == 0 || len(config.ClientKeyPEM) == 0 {
+			return nil, errors.New("both the client certificate and the client key must be provided for mutual TLS")
+		}
+
+		certificate, err := tls.X509KeyPair(config.ClientCertPEM, config.ClientKeyPEM)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"}`))
+	}))
+	defer server.Close()
+
+	caCertPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	tests := map[string]struct {
+		config      TLSConfig
+		expectError bool
+	}{
+		"untrusted server certificate": {
+			config:      TLSConfig{},
+			expectError: true,
+		},
+		"custom CA certificate": {
+			config: TLSConfig{CACertPEM: caCertPEM},
+		},
+		"insecure skip verify": {
+			config: TLSConfig{InsecureSkipVerify: true},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0), WithTLS(test.config))
+
+			_, err := dnsmasq.ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55")
+			if test.expectError && err == nil {
+				t.Error("expected an error")
+			}
+			if !test.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestTLSInvalidConfig(t *testing.T) {
+	tests := map[string]TLSConfig{
+		"invalid CA certificate":  {CACertPEM: []byte("not a certificate")},
+		"client cert without key": {ClientCertPEM: []byte("-----BEGIN CERTIFICATE-----")},
+	}
+
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := New("https://localhost", "", WithTLS(config)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestTransportProxy(t *testing.T) {
+	var proxiedURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedURL = r.URL.String()
+		_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"}`))
+	}))
+	defer proxy.Close()
+
+	dnsmasq := newTestClient(t, "http://dnsmasq-manager.example", WithRetry(0, 0, 0), WithTransport(TransportConfig{Proxy: proxy.URL}))
+	if _, err := dnsmasq.ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if proxiedURL != "http://dnsmasq-manager.example/api/v1/static/host?mac=00%3A11%3A22%3A33%3A44%3A55" {
+		t.Errorf("request not sent through the proxy, got: %q", proxiedURL)
+	}
+}
+
+func TestTransportRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0), WithTransport(TransportConfig{RequestTimeout: 50 * time.Millisecond}))
+	if _, err := dnsmasq.ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55"); err == nil {
+		t.Error("expected a timeout error")
+	}
+}
+
+func TestTransportInvalidProxy(t *testing.T) {
+	if _, err := New("http://localhost", "", WithTransport(TransportConfig{Proxy: "not a url"})); err == nil {
+		t.Error("expected an error")
+	}
+}
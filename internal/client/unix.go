@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// unixSocketHost is the host name sent to dnsmasq-manager in requests made
+// through a Unix domain socket.
+const unixSocketHost = "localhost"
+
+// IsUnixSocketURL reports whether apiUrl points to a Unix domain socket.
+func IsUnixSocketURL(apiUrl string) bool {
+	_, _, isUnixSocket, _ := parseUnixSocketURL(apiUrl)
+	return isUnixSocket
+}
+
+// parseUnixSocketURL parses API URLs pointing to a Unix domain socket, such as
+// unix:///run/dnsmasq-manager.sock. An optional HTTP path prefix can follow
+// the socket path, separated by a colon:
+//
+//	unix:///run/dnsmasq-manager.sock:/dnsmasq
+//
+// It reports whether apiUrl uses the unix scheme.
+func parseUnixSocketURL(apiUrl string) (socketPath string, prefix string, ok bool, err error) {
+	parsed, err := url.Parse(apiUrl)
+	if err != nil || parsed.Scheme != "unix" {
+		return "", "", false, nil
+	}
+
+	if parsed.Host != "" {
+		return "", "", true, fmt.Errorf("invalid Unix socket URL %q: the socket path must be absolute, e.g. unix:///run/dnsmasq-manager.sock", apiUrl)
+	}
+
+	socketPath, prefix, _ = strings.Cut(parsed.Path, ":")
+	if socketPath == "" {
+		return "", "", true, fmt.Errorf("invalid Unix socket URL %q: missing socket path", apiUrl)
+	}
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+
+	return socketPath, strings.TrimSuffix(prefix, "/"), true, nil
+}
+
+// dialUnixSocket makes the client transport connect to the given Unix domain
+// socket, whatever the address of the request. It must be applied after the
+// options so the configured dial timeout is kept.
+func (c *dnsmasqManagerClient) dialUnixSocket(socketPath string) {
+	dial := c.transport.DialContext
+	c.transport.DialContext = func(ctx context.Context, network string, address string) (net.Conn, error) {
+		return dial(ctx, "unix", socketPath)
+	}
+
+	// A proxy cannot reach a local socket.
+	c.transport.Proxy = nil
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "dnsmasq-manager.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requestPath string
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestPath = r.URL.Path
+		_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"}`))
+	})}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	tests := map[string]struct {
+		apiUrl       string
+		expectedPath string
+	}{
+		"socket": {
+			apiUrl:       "unix://" + socketPath,
+			expectedPath: "/api/v1/static/host",
+		},
+		"socket with path prefix": {
+			apiUrl:       "unix://" + socketPath + ":/dnsmasq/",
+			expectedPath: "/dnsmasq/api/v1/static/host",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dnsmasq := newTestClient(t, test.apiUrl, WithRetry(0, 0, 0), WithTransport(TransportConfig{Proxy: "http://proxy.invalid:3128"}))
+
+			host, err := dnsmasq.ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if host.HostName != "example" {
+				t.Errorf("unexpected host %+v", host)
+			}
+			if requestPath != test.expectedPath {
+				t.Errorf("expected request path %q, got %q", test.expectedPath, requestPath)
+			}
+		})
+	}
+}
+
+func TestParseUnixSocketURL(t *testing.T) {
+	tests := map[string]struct {
+		apiUrl       string
+		socketPath   string
+		prefix       string
+		isUnixSocket bool
+		expectError  bool
+	}{
+		"http url":        {apiUrl: "http://localhost:6904"},
+		"socket":          {apiUrl: "unix:///run/dmm.sock", socketPath: "/run/dmm.sock", isUnixSocket: true},
+		"socket prefix":   {apiUrl: "unix:///run/dmm.sock:/api-gw", socketPath: "/run/dmm.sock", prefix: "/api-gw", isUnixSocket: true},
+		"relative socket": {apiUrl: "unix://run/dmm.sock", isUnixSocket: true, expectError: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			socketPath, prefix, isUnixSocket, err := parseUnixSocketURL(test.apiUrl)
+			if test.expectError != (err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if socketPath != test.socketPath || prefix != test.prefix || isUnixSocket != test.isUnixSocket {
+				t.Errorf("unexpected result %q %q %t", socketPath, prefix, isUnixSocket)
+			}
+		})
+	}
+}
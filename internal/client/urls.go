@@ -0,0 +1,56 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ValidateURL checks that apiUrl can be used to reach dnsmasq-manager: either
+// an absolute http or https URL, optionally including the base path the API
+// is published under, or a Unix socket URL.
+func ValidateURL(apiUrl string) error {
+	if _, _, isUnixSocket, err := parseUnixSocketURL(apiUrl); isUnixSocket {
+		return err
+	}
+
+	_, err := parseBaseURL(apiUrl)
+	return err
+}
+
+// parseBaseURL parses the URL of a dnsmasq-manager endpoint. Its path, with or
+// without a trailing slash, prefixes the path of every API route.
+func parseBaseURL(apiUrl string) (*url.URL, error) {
+	base, err := url.Parse(apiUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API URL %q: %w", apiUrl, err)
+	}
+
+	switch {
+	case base.Scheme != "http" && base.Scheme != "https":
+		return nil, fmt.Errorf("invalid API URL %q: the scheme must be http, https or unix", apiUrl)
+	case base.Host == "":
+		return nil, fmt.Errorf("invalid API URL %q: missing host", apiUrl)
+	case base.RawQuery != "" || base.Fragment != "":
+		return nil, fmt.Errorf("invalid API URL %q: query strings and fragments are not supported", apiUrl)
+	}
+
+	return base, nil
+}
+
+// apiPath returns the reference of an API route relative to the endpoint base
+// URL, with the query values escaped.
+func apiPath(path string, query url.Values) string {
+	reference := url.URL{Path: path, RawQuery: query.Encode()}
+	return reference.String()
+}
+
+// resolveURL returns the URL of the API route reference, as built by apiPath,
+// under base.
+func resolveURL(base *url.URL, reference string) string {
+	path, rawQuery, _ := strings.Cut(reference, "?")
+
+	resolved := base.JoinPath(path)
+	resolved.RawQuery = rawQuery
+	return resolved.String()
+}
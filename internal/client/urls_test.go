@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestResolveURL(t *testing.T) {
+	tests := map[string]struct {
+		apiUrl    string
+		reference string
+		expectURL string
+	}{
+		"root": {
+			apiUrl:    "http://localhost:6904",
+			reference: apiPath("/api/v1/info", nil),
+			expectURL: "http://localhost:6904/api/v1/info",
+		},
+		"root with trailing slash": {
+			apiUrl:    "http://localhost:6904/",
+			reference: apiPath("/api/v1/info", nil),
+			expectURL: "http://localhost:6904/api/v1/info",
+		},
+		"base path": {
+			apiUrl:    "https://gw.example/dnsmasq",
+			reference: apiPath("/api/v1/info", nil),
+			expectURL: "https://gw.example/dnsmasq/api/v1/info",
+		},
+		"base path with trailing slash": {
+			apiUrl:    "https://gw.example/dnsmasq/",
+			reference: apiPath("/api/v1/info", nil),
+			expectURL: "https://gw.example/dnsmasq/api/v1/info",
+		},
+		"escaped query": {
+			apiUrl:    "https://gw.example/dnsmasq/",
+			reference: apiPath("/api/v1/static/host", url.Values{"mac": {"00:11:22:33:44:55&mac=x"}}),
+			expectURL: "https://gw.example/dnsmasq/api/v1/static/host?mac=00%3A11%3A22%3A33%3A44%3A55%26mac%3Dx",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			base, err := parseBaseURL(test.apiUrl)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resolved := resolveURL(base, test.reference); resolved != test.expectURL {
+				t.Errorf("expected %q, got %q", test.expectURL, resolved)
+			}
+		})
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := map[string]struct {
+		apiUrl      string
+		expectError bool
+	}{
+		"http":            {apiUrl: "http://localhost:6904"},
+		"https base path": {apiUrl: "https://gw.example/dnsmasq/"},
+		"unix socket":     {apiUrl: "unix:///run/dmm.sock:/dnsmasq"},
+		"missing scheme":  {apiUrl: "localhost:6904", expectError: true},
+		"unknown scheme":  {apiUrl: "ftp://localhost", expectError: true},
+		"missing host":    {apiUrl: "http:///api", expectError: true},
+		"query string":    {apiUrl: "http://localhost:6904/?debug=1", expectError: true},
+		"invalid url":     {apiUrl: "http://local host", expectError: true},
+		"relative socket": {apiUrl: "unix://run/dmm.sock", expectError: true},
+		"empty socket":    {apiUrl: "unix://", expectError: true},
+		"empty":           {apiUrl: "", expectError: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateURL(test.apiUrl)
+			if test.expectError && err == nil {
+				t.Error("expected an error")
+			}
+			if !test.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	var requestURL *url.URL
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURL = r.URL
+		_, _ = w.Write([]byte(`{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"}`))
+	}))
+	defer server.Close()
+
+	dnsmasq := newTestClient(t, server.URL+"/dnsmasq/", WithRetry(0, 0, 0))
+	if _, err := dnsmasq.ReadStaticDhcpHost(context.Background(), "00:11:22:33:44:55"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if requestURL.Path != "/dnsmasq/api/v1/static/host" {
+		t.Errorf("expected the base path to prefix the route, got %q", requestURL.Path)
+	}
+	if mac := requestURL.Query().Get("mac"); mac != "00:11:22:33:44:55" {
+		t.Errorf("expected the MAC address in the query, got %q", mac)
+	}
+}
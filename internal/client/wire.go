@@ -0,0 +1,120 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// hostCodec converts static hosts to and from the payload of a version of the
+// dnsmasq-manager API. StaticDhcpHost is never sent as is, so fields added to
+// it do not leak into the API.
+type hostCodec interface {
+	encode(host StaticDhcpHost) (any, error)
+	decode(data []byte) (StaticDhcpHost, error)
+	// routePrefix returns the path prefix of the static host routes of the
+	// API version, so each payload format is only sent to the routes
+	// expecting it. The server information and login routes are not
+	// versioned.
+	routePrefix() string
+}
+
+// staticDhcpHostV1JSON is the static host payload of API version 1, which
+// uses the Go field names of the original server implementation.
+type staticDhcpHostV1JSON struct {
+	MacAddress string `json:"MacAddress"`
+	IPAddress  string `json:"IPAddress"`
+	HostName   string `json:"HostName"`
+}
+
+// staticDhcpHostV2JSON is the static host payload of API version 2.
+type staticDhcpHostV2JSON struct {
+	MacAddress string   `json:"mac_address"`
+	IPAddress  string   `json:"ip_address"`
+	HostName   string   `json:"hostname"`
+	LeaseTime  string   `json:"lease_time,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+type hostCodecV1 struct{}
+
+func (hostCodecV1) encode(host StaticDhcpHost) (any, error) {
+	if host.LeaseTime != "" || len(host.Tags) > 0 {
+		return nil, errors.New("lease time and tags require dnsmasq-manager API version 2")
+	}
+
+	return &staticDhcpHostV1JSON{
+		MacAddress: host.MacAddress,
+		IPAddress:  host.IPAddress,
+		HostName:   host.HostName,
+	}, nil
+}
+
+func (hostCodecV1) decode(data []byte) (StaticDhcpHost, error) {
+	payload := staticDhcpHostV1JSON{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return StaticDhcpHost{}, err
+	}
+
+	return StaticDhcpHost{
+		MacAddress: payload.MacAddress,
+		IPAddress:  payload.IPAddress,
+		HostName:   payload.HostName,
+	}, nil
+}
+
+func (hostCodecV1) routePrefix() string {
+	return "/api/v1"
+}
+
+type hostCodecV2 struct{}
+
+func (hostCodecV2) encode(host StaticDhcpHost) (any, error) {
+	return &staticDhcpHostV2JSON{
+		MacAddress: host.MacAddress,
+		IPAddress:  host.IPAddress,
+		HostName:   host.HostName,
+		LeaseTime:  host.LeaseTime,
+		Tags:       host.Tags,
+	}, nil
+}
+
+func (hostCodecV2) decode(data []byte) (StaticDhcpHost, error) {
+	payload := staticDhcpHostV2JSON{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return StaticDhcpHost{}, err
+	}
+
+	return StaticDhcpHost{
+		MacAddress: payload.MacAddress,
+		IPAddress:  payload.IPAddress,
+		HostName:   payload.HostName,
+		LeaseTime:  payload.LeaseTime,
+		Tags:       payload.Tags,
+	}, nil
+}
+
+func (hostCodecV2) routePrefix() string {
+	return "/api/v2"
+}
+
+// hostCodec returns the codec of the API version negotiated by Discover,
+// version 1 until the server has been discovered.
+func (c *dnsmasqManagerClient) hostCodec() hostCodec {
+	if info := c.ServerInfo(); info != nil && apiMajorVersion(info.APIVersion) >= 2 {
+		return hostCodecV2{}
+	}
+	return hostCodecV1{}
+}
+
+// apiMajorVersion returns the major version of an API version such as "2.1",
+// or 0 when it cannot be parsed.
+func apiMajorVersion(version string) int {
+	major, _, _ := strings.Cut(version, ".")
+	number, err := strconv.Atoi(major)
+	if err != nil {
+		return 0
+	}
+	return number
+}
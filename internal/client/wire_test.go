@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestWireFormat(t *testing.T) {
+	host := StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "1.2.3.4", HostName: "example"}
+	extended := StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "1.2.3.4", HostName: "example", LeaseTime: "12h", Tags: []string{"printers"}}
+
+	tests := map[string]struct {
+		apiVersion  string
+		host        StaticDhcpHost
+		expectPath  string
+		expectBody  string
+		expectError bool
+	}{
+		"legacy server": {
+			host:       host,
+			expectPath: "/api/v1/static/host",
+			expectBody: `{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"}`,
+		},
+		"version 1": {
+			apiVersion: "1.2",
+			host:       host,
+			expectPath: "/api/v1/static/host",
+			expectBody: `{"MacAddress":"00:11:22:33:44:55","IPAddress":"1.2.3.4","HostName":"example"}`,
+		},
+		"version 1 with lease time and tags": {
+			apiVersion:  "1.2",
+			host:        extended,
+			expectError: true,
+		},
+		"version 2": {
+			apiVersion: "2.0",
+			host:       host,
+			expectPath: "/api/v2/static/host",
+			expectBody: `{"mac_address":"00:11:22:33:44:55","ip_address":"1.2.3.4","hostname":"example"}`,
+		},
+		"version 2 with lease time and tags": {
+			apiVersion: "2.0",
+			host:       extended,
+			expectPath: "/api/v2/static/host",
+			expectBody: `{"mac_address":"00:11:22:33:44:55","ip_address":"1.2.3.4","hostname":"example","lease_time":"12h","tags":["printers"]}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var requestPath, body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/v1/info" {
+					if test.apiVersion == "" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					_ = json.NewEncoder(w).Encode(&serverInfoJSON{APIVersion: test.apiVersion, Features: []Feature{FeatureStaticHosts}})
+					return
+				}
+
+				data, _ := io.ReadAll(r.Body)
+				requestPath, body = r.URL.Path, string(data)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write(data)
+			}))
+			defer server.Close()
+
+			dnsmasq := newTestClient(t, server.URL, WithRetry(0, 0, 0))
+			if _, err := dnsmasq.Discover(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			created, err := dnsmasq.CreateStaticDhcpHost(context.Background(), test.host)
+			if test.expectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if requestPath != test.expectPath {
+				t.Errorf("expected a request to %s, got %s", test.expectPath, requestPath)
+			}
+			if body != test.expectBody {
+				t.Errorf("expected body %s, got %s", test.expectBody, body)
+			}
+			if !reflect.DeepEqual(*created, test.host) {
+				t.Errorf("expected %+v, got %+v", test.host, *created)
+			}
+		})
+	}
+}
+
+func TestAPIMajorVersion(t *testing.T) {
+	tests := map[string]int{
+		"1.0":     1,
+		"2":       2,
+		"2.1.3":   2,
+		"unknown": 0,
+		"":        0,
+	}
+
+	for version, expected := range tests {
+		if major := apiMajorVersion(version); major != expected {
+			t.Errorf("expected major version %d for %q, got %d", expected, version, major)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package dnsmasqtest
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Operations of the batch route.
+const (
+	operationCreate = "create"
+	operationUpdate = "update"
+	operationDelete = "delete"
+)
+
+const defaultPageSize = 100
+
+type hostV1JSON struct {
+	MacAddress string `json:"MacAddress"`
+	IPAddress  string `json:"IPAddress"`
+	HostName   string `json:"HostName"`
+}
+
+type hostV2JSON struct {
+	MacAddress string   `json:"mac_address"`
+	IPAddress  string   `json:"ip_address"`
+	HostName   string   `json:"hostname"`
+	LeaseTime  string   `json:"lease_time,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// hostKey normalizes MAC addresses, which are matched case insensitively.
+func hostKey(macAddress string) string {
+	return strings.ToLower(macAddress)
+}
+
+func (s *Server) apiV2() bool {
+	major, _, _ := strings.Cut(s.apiVersion, ".")
+	number, _ := strconv.Atoi(major)
+	return number >= 2
+}
+
+// RoutePrefix returns the path prefix of the static host routes of the API
+// version, such as /api/v2.
+func (s *Server) RoutePrefix() string {
+	if s.apiV2() {
+		return "/api/v2"
+	}
+	return "/api/v1"
+}
+
+// encodeHost returns the payload of host in the format of the API version.
+func (s *Server) encodeHost(host Host) any {
+	if s.apiV2() {
+		return &hostV2JSON{MacAddress: host.MacAddress, IPAddress: host.IPAddress, HostName: host.HostName, LeaseTime: host.LeaseTime, Tags: host.Tags}
+	}
+	return &hostV1JSON{MacAddress: host.MacAddress, IPAddress: host.IPAddress, HostName: host.HostName}
+}
+
+// decodeHost parses a payload in the format of the API version.
+func (s *Server) decodeHost(data []byte) (Host, error) {
+	if s.apiV2() {
+		payload := hostV2JSON{}
+		err := json.Unmarshal(data, &payload)
+		return Host{MacAddress: payload.MacAddress, IPAddress: payload.IPAddress, HostName: payload.HostName, LeaseTime: payload.LeaseTime, Tags: payload.Tags}, err
+	}
+
+	payload := hostV1JSON{}
+	err := json.Unmarshal(data, &payload)
+	return Host{MacAddress: payload.MacAddress, IPAddress: payload.IPAddress, HostName: payload.HostName}, err
+}
+
+func (s *Server) storeLocked(host Host) string {
+	key := hostKey(host.MacAddress)
+	s.revision++
+	s.hosts[key] = host
+	s.revisions[key] = s.revision
+	return etag(s.revision)
+}
+
+// applyLocked applies a change to the stored hosts. It returns the status of
+// the outcome with either the resulting host and revision or an error.
+func (s *Server) applyLocked(operation string, host Host, ifMatch string) (int, Host, string, *errorJSON) {
+	key := hostKey(host.MacAddress)
+	stored, exists := s.hosts[key]
+
+	switch {
+	case host.MacAddress == "":
+		return http.StatusBadRequest, Host{}, "", &errorJSON{Error: "validation_failed", Message: "Missing MAC address"}
+	case operation != operationDelete && net.ParseIP(host.IPAddress) == nil:
+		return http.StatusBadRequest, Host{}, "", &errorJSON{Error: "validation_failed", Message: "Invalid IP address", Details: host.IPAddress}
+	case operation == operationCreate && exists:
+		return http.StatusConflict, Host{}, "", &errorJSON{Error: "conflict", Message: "Static DHCP host already exists"}
+	case operation != operationCreate && !exists:
+		return http.StatusNotFound, Host{}, "", &errorJSON{Error: "not_found", Message: "Static DHCP host not found"}
+	case ifMatch != "" && ifMatch != etag(s.revisions[key]):
+		return http.StatusPreconditionFailed, Host{}, "", &errorJSON{Error: "precondition_failed", Message: "Static DHCP host was modified"}
+	}
+
+	if operation == operationDelete {
+		revision := etag(s.revisions[key])
+		delete(s.hosts, key)
+		delete(s.revisions, key)
+		return http.StatusOK, stored, revision, nil
+	}
+
+	return http.StatusCreated, host, s.storeLocked(host), nil
+}
+
+func (s *Server) handleReadHost(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := hostKey(r.URL.Query().Get("mac"))
+	host, ok := s.hosts[key]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "Static DHCP host not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, etag(s.revisions[key]), s.encodeHost(host))
+}
+
+func (s *Server) handleWriteHost(w http.ResponseWriter, r *http.Request) {
+	data, _ := io.ReadAll(r.Body)
+	host, err := s.decodeHost(data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "validation_failed", "Invalid static DHCP host")
+		return
+	}
+
+	operation := operationCreate
+	if r.Method == http.MethodPut {
+		operation = operationUpdate
+	}
+
+	s.respondApply(w, operation, host, r.Header.Get("If-Match"))
+}
+
+func (s *Server) handleDeleteHost(w http.ResponseWriter, r *http.Request) {
+	s.respondApply(w, operationDelete, Host{MacAddress: r.URL.Query().Get("mac")}, r.Header.Get("If-Match"))
+}
+
+func (s *Server) respondApply(w http.ResponseWriter, operation string, host Host, ifMatch string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, host, revision, errPayload := s.applyLocked(operation, host, ifMatch)
+	if errPayload != nil {
+		writeJSON(w, status, "", errPayload)
+		return
+	}
+
+	writeJSON(w, status, revision, s.encodeHost(host))
+}
+
+type pageJSON struct {
+	Hosts         []map[string]any `json:"hosts"`
+	NextPageToken string           `json:"next_page_token,omitempty"`
+}
+
+func (s *Server) handleListHosts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var network *net.IPNet
+	if cidr := query.Get("cidr"); cidr != "" {
+		var err error
+		if _, network, err = net.ParseCIDR(cidr); err != nil {
+			writeError(w, http.StatusBadRequest, "validation_failed", "Invalid CIDR")
+			return
+		}
+	}
+	pageSize := defaultPageSize
+	if size, err := strconv.Atoi(query.Get("page_size")); err == nil && size > 0 {
+		pageSize = size
+	}
+	offset, _ := strconv.Atoi(query.Get("page_token"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.hosts))
+	for key, host := range s.hosts {
+		if network != nil && !network.Contains(net.ParseIP(host.IPAddress)) {
+			continue
+		}
+		if pattern := query.Get("hostname"); pattern != "" {
+			if matched, _ := path.Match(pattern, host.HostName); !matched {
+				continue
+			}
+		}
+		if !strings.HasPrefix(key, hostKey(query.Get("mac_prefix"))) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	page := pageJSON{Hosts: []map[string]any{}}
+	for i := offset; i < len(keys) && i < offset+pageSize; i++ {
+		listed := s.listedHost(s.hosts[keys[i]])
+		listed["revision"] = etag(s.revisions[keys[i]])
+		page.Hosts = append(page.Hosts, listed)
+	}
+	if offset+pageSize < len(keys) {
+		page.NextPageToken = strconv.Itoa(offset + pageSize)
+	}
+
+	writeJSON(w, http.StatusOK, "", &page)
+}
+
+// listedHost returns the payload of host as a map, so the revision can be
+// added alongside its fields.
+func (s *Server) listedHost(host Host) map[string]any {
+	data, _ := json.Marshal(s.encodeHost(host))
+	listed := map[string]any{}
+	_ = json.Unmarshal(data, &listed)
+	return listed
+}
+
+type batchRequestJSON struct {
+	Operations []struct {
+		Operation string          `json:"op"`
+		Host      json.RawMessage `json:"host"`
+		Revision  string          `json:"revision"`
+	} `json:"operations"`
+}
+
+type batchResultJSON struct {
+	Status   int        `json:"status"`
+	Host     any        `json:"host,omitempty"`
+	Revision string     `json:"revision,omitempty"`
+	Error    *errorJSON `json:"error,omitempty"`
+}
+
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	request := batchRequestJSON{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_failed", "Invalid batch request")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]batchResultJSON, len(request.Operations))
+	for i, operation := range request.Operations {
+		host, err := s.decodeHost(operation.Host)
+		switch {
+		case err != nil:
+			results[i] = batchResultJSON{Status: http.StatusBadRequest, Error: &errorJSON{Error: "validation_failed", Message: "Invalid static DHCP host"}}
+			continue
+		case operation.Operation != operationCreate && operation.Operation != operationUpdate && operation.Operation != operationDelete:
+			results[i] = batchResultJSON{Status: http.StatusBadRequest, Error: &errorJSON{Error: "validation_failed", Message: "Invalid operation", Details: operation.Operation}}
+			continue
+		}
+
+		status, host, revision, errPayload := s.applyLocked(operation.Operation, host, operation.Revision)
+		if errPayload != nil {
+			results[i] = batchResultJSON{Status: status, Error: errPayload}
+			continue
+		}
+		results[i] = batchResultJSON{Status: status, Host: s.encodeHost(host), Revision: revision}
+	}
+
+	writeJSON(w, http.StatusOK, "", &struct {
+		Results []batchResultJSON `json:"results"`
+	}{Results: results})
+}
@@ -0,0 +1,394 @@
+// Package dnsmasqtest provides an in-process fake dnsmasq-manager for tests.
+//
+// The fake keeps its static hosts in memory and implements the routes used by
+// the provider: server information, login, single host operations with ETag
+// revisions, listing and batches. It can require JWT authentication, fail
+// requests on demand and records every request it receives. Its responses
+// follow the OpenAPI document of the client, internal/client/openapi.json,
+// which the client contract tests check.
+package dnsmasqtest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Feature flags advertised by the fake, matching the client.Feature values.
+const (
+	FeatureStaticHosts      = "static_hosts"
+	FeatureListStaticHosts  = "list_static_hosts"
+	FeatureBatchStaticHosts = "batch_static_hosts"
+)
+
+// Host is a static DHCP host stored by the fake. LeaseTime and Tags are only
+// exchanged with clients when the fake speaks API version 2.
+type Host struct {
+	MacAddress string
+	IPAddress  string
+	HostName   string
+	LeaseTime  string
+	Tags       []string
+}
+
+// Request is a request received by the fake.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Option configures the fake returned by NewServer.
+type Option func(*Server)
+
+// WithAPIVersion sets the API version advertised by the fake, which also
+// selects the static host payload format. An empty version makes the fake
+// behave like a server predating the info route. Defaults to 1.0.
+func WithAPIVersion(version string) Option {
+	return func(s *Server) {
+		s.apiVersion = version
+	}
+}
+
+// WithFeatures sets the features advertised by the fake. Defaults to every
+// feature.
+func WithFeatures(features ...string) Option {
+	return func(s *Server) {
+		s.features = features
+	}
+}
+
+// WithUser makes the fake require a JWT on every route but the server
+// information, obtained by logging in with the username and password or
+// minted with Token.
+func WithUser(username string, password string) Option {
+	return func(s *Server) {
+		s.users[username] = password
+	}
+}
+
+// WithTokenTTL sets the lifetime of the tokens issued by the fake. Defaults
+// to one hour.
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.tokenTTL = ttl
+	}
+}
+
+// Server is a fake dnsmasq-manager listening on a local port.
+type Server struct {
+	*httptest.Server
+
+	apiVersion string
+	features   []string
+	users      map[string]string
+	tokenTTL   time.Duration
+	secret     []byte
+
+	mu        sync.Mutex
+	hosts     map[string]Host
+	revisions map[string]int
+	revision  int
+	faults    []*fault
+	requests  []Request
+}
+
+type fault struct {
+	method    string
+	path      string
+	status    int
+	times     int
+	processed bool
+}
+
+// NewServer starts a fake dnsmasq-manager. It must be closed when no longer
+// used.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		apiVersion: "1.0",
+		features:   []string{FeatureStaticHosts, FeatureListStaticHosts, FeatureBatchStaticHosts},
+		users:      map[string]string{},
+		tokenTTL:   time.Hour,
+		secret:     make([]byte, 32),
+		hosts:      map[string]Host{},
+		revisions:  map[string]int{},
+	}
+	_, _ = rand.Read(s.secret)
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/info", s.handleInfo)
+	mux.HandleFunc("POST /api/v1/auth/login", s.handleLogin)
+	// The static host routes only accept the payload format of the API
+	// version, under the path prefix of that version.
+	prefix := s.RoutePrefix()
+	mux.HandleFunc("GET "+prefix+"/static/host", s.authenticated(s.handleReadHost))
+	mux.HandleFunc("POST "+prefix+"/static/host", s.authenticated(s.handleWriteHost))
+	mux.HandleFunc("PUT "+prefix+"/static/host", s.authenticated(s.handleWriteHost))
+	mux.HandleFunc("DELETE "+prefix+"/static/host", s.authenticated(s.handleDeleteHost))
+	mux.HandleFunc("GET "+prefix+"/static/hosts", s.authenticated(s.handleListHosts))
+	mux.HandleFunc("POST "+prefix+"/static/hosts/batch", s.authenticated(s.handleBatch))
+
+	s.Server = httptest.NewServer(s.record(mux))
+	return s
+}
+
+// SetHost stores a host, as if it was created outside of the tests, and
+// returns its revision.
+func (s *Server) SetHost(host Host) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.storeLocked(host)
+}
+
+// Host returns the stored host with the given MAC address.
+func (s *Server) Host(macAddress string) (Host, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	host, ok := s.hosts[hostKey(macAddress)]
+	return host, ok
+}
+
+// DeleteHost removes a host, as if it was deleted outside of the tests.
+func (s *Server) DeleteHost(macAddress string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.hosts, hostKey(macAddress))
+}
+
+// InjectError makes the next times requests matching the method and path
+// fail with status. An empty method or path matches any request.
+func (s *Server) InjectError(method string, path string, status int, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault{method: method, path: path, status: status, times: times})
+}
+
+// InjectLostResponse makes the next times requests matching the method and
+// path be processed as usual, but answered with status instead of their
+// response, like a proxy losing the response of the server. An empty method
+// or path matches any request.
+func (s *Server) InjectLostResponse(method string, path string, status int, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault{method: method, path: path, status: status, times: times, processed: true})
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ResetRequests forgets the requests received so far.
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// Token mints a token accepted by the fake.
+func (s *Server) Token() string {
+	return s.sign("test", time.Now().Add(s.tokenTTL))
+}
+
+// record records every request and fails those matching an injected error
+// or lost response.
+func (s *Server) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Query:  r.URL.Query(),
+			Header: r.Header.Clone(),
+			Body:   body,
+		})
+		status, processed := s.takeFaultLocked(r)
+		s.mu.Unlock()
+
+		if processed {
+			next.ServeHTTP(httptest.NewRecorder(), r)
+		}
+		if status != 0 {
+			if status == http.StatusServiceUnavailable || status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			writeError(w, status, "injected_error", "Injected error")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) takeFaultLocked(r *http.Request) (int, bool) {
+	for i, f := range s.faults {
+		if (f.method != "" && f.method != r.Method) || (f.path != "" && f.path != r.URL.Path) {
+			continue
+		}
+
+		f.times--
+		if f.times <= 0 {
+			s.faults = append(s.faults[:i], s.faults[i+1:]...)
+		}
+		return f.status, f.processed
+	}
+	return 0, false
+}
+
+type serverInfoJSON struct {
+	APIVersion     string   `json:"api_version"`
+	ManagerVersion string   `json:"version"`
+	DnsmasqVersion string   `json:"dnsmasq_version"`
+	Features       []string `json:"features"`
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if s.apiVersion == "" {
+		writeError(w, http.StatusNotFound, "not_found", "Not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "", &serverInfoJSON{
+		APIVersion:     s.apiVersion,
+		ManagerVersion: "0.6.0",
+		DnsmasqVersion: "2.90",
+		Features:       s.features,
+	})
+}
+
+type loginRequestJSON struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type loginResponseJSON struct {
+	Token string `json:"token"`
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	login := loginRequestJSON{}
+	if err := json.NewDecoder(r.Body).Decode(&login); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_failed", "Invalid login request")
+		return
+	}
+
+	password, ok := s.users[login.Username]
+	if !ok || password != login.Password {
+		writeError(w, http.StatusUnauthorized, "unauthorized", "Invalid username or password")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "", &loginResponseJSON{Token: s.sign(login.Username, time.Now().Add(s.tokenTTL))})
+}
+
+// authenticated requires a valid token when the fake has users.
+func (s *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(s.users) > 0 {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || !s.verify(token) {
+				writeError(w, http.StatusUnauthorized, "unauthorized", "Missing or invalid token")
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+type claimsJSON struct {
+	Subject string `json:"sub"`
+	Expiry  int64  `json:"exp"`
+}
+
+// sign issues an HS256 JWT.
+func (s *Server) sign(subject string, expiry time.Time) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(&claimsJSON{Subject: subject, Expiry: expiry.Unix()})
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify reports whether the token was issued by the fake and has not
+// expired.
+func (s *Server) verify(token string) bool {
+	unsigned, signature, ok := cutLast(token, ".")
+	if !ok {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(unsigned))
+	expected := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return false
+	}
+
+	_, payload, _ := cutLast(unsigned, ".")
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return false
+	}
+	claims := claimsJSON{}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return false
+	}
+
+	return time.Now().Unix() < claims.Expiry
+}
+
+func cutLast(s string, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return "", "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+type errorJSON struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Details string `json:"details,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	writeJSON(w, status, "", &errorJSON{Error: code, Message: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, etag string, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+func etag(revision int) string {
+	return fmt.Sprintf(`"%d"`, revision)
+}
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"terraform-provider-dnsmasq/internal/client"
 
@@ -66,7 +67,7 @@ func (d *DhcpStaticHostDataSource) Configure(ctx context.Context, req datasource
 		return
 	}
 
-	client, ok := req.ProviderData.(client.Client)
+	dnsmasq, ok := req.ProviderData.(client.Client)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -77,7 +78,8 @@ func (d *DhcpStaticHostDataSource) Configure(ctx context.Context, req datasource
 		return
 	}
 
-	d.client = client
+	resp.Diagnostics.Append(checkServerFeature(dnsmasq, client.FeatureStaticHosts, "dnsmasq_dhcp_static_host")...)
+	d.client = dnsmasq
 }
 
 func (d *DhcpStaticHostDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
@@ -89,9 +91,19 @@ func (d *DhcpStaticHostDataSource) Read(ctx context.Context, req datasource.Read
 		return
 	}
 
-	host, err := d.client.ReadStaticDhcpHost(data.MacAddress.ValueString())
+	host, err := d.client.ReadStaticDhcpHost(ctx, data.MacAddress.ValueString())
+	var divergence *client.ReplicaDivergenceError
+	if errors.As(err, &divergence) && divergence.Host != nil {
+		// Read the reservation of the primary server, like the resource.
+		addReplicaDivergenceWarning(&resp.Diagnostics, divergence)
+		host, err = divergence.Host, nil
+	}
 	if err != nil {
-		resp.Diagnostics.AddError("Unable to read DHCP Static Host", err.Error())
+		addClientError(&resp.Diagnostics, "Unable to read DHCP Static Host", err)
+		return
+	}
+	if host == nil {
+		addMissingHostError(&resp.Diagnostics, "Unable to read DHCP Static Host")
 		return
 	}
 
@@ -1,9 +1,14 @@
 package provider
 
 import (
+	"context"
 	"terraform-provider-dnsmasq/internal/client"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
 )
@@ -35,15 +40,130 @@ data "dnsmasq_dhcp_static_host" "test" {
 `
 
 func setupDhcpStaticHostDataSourceTest(t *testing.T) {
-	dnsmasq := client.New(apiUrl, "")
-	_, err := dnsmasq.CreateStaticDhcpHost(client.StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "1.2.3.4", HostName: "example"})
+	dnsmasq, err := testAccClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dnsmasq.CreateStaticDhcpHost(context.Background(), client.StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "1.2.3.4", HostName: "example"})
 	if err != nil {
 		t.Error(err)
 	}
 }
 
 func teardownDhcpStaticHostDataSourceTest(*terraform.State) error {
-	dnsmasq := client.New(apiUrl, "")
-	_, err := dnsmasq.DeleteStaticDhcpHost("00:11:22:33:44:55")
+	dnsmasq, err := testAccClient()
+	if err != nil {
+		return err
+	}
+	_, err = dnsmasq.DeleteStaticDhcpHost(context.Background(), "00:11:22:33:44:55", "")
 	return err
 }
+
+func TestDhcpStaticHostDataSourceRead(t *testing.T) {
+	tests := map[string]struct {
+		hosts          []client.StaticDhcpHost
+		err            error
+		macAddress     string
+		expectState    *DhcpStaticHostDataSourceModel
+		expectErrors   []string
+		expectWarnings []string
+		expectDetail   string
+	}{
+		"existing host": {
+			hosts:      []client.StaticDhcpHost{testExistingHost},
+			macAddress: "00:11:22:33:44:55",
+			expectState: &DhcpStaticHostDataSourceModel{
+				MacAddress: types.StringValue("00:11:22:33:44:55"),
+				IPAddress:  types.StringValue("10.0.0.5"),
+				HostName:   types.StringValue("example"),
+				Id:         types.StringValue("00:11:22:33:44:55"),
+			},
+		},
+		"uppercase MAC address": {
+			hosts:      []client.StaticDhcpHost{{MacAddress: "00:aa:bb:cc:dd:ee", IPAddress: "10.0.0.5", HostName: "example"}},
+			macAddress: "00:AA:BB:CC:DD:EE",
+			expectState: &DhcpStaticHostDataSourceModel{
+				MacAddress: types.StringValue("00:AA:BB:CC:DD:EE"),
+				IPAddress:  types.StringValue("10.0.0.5"),
+				HostName:   types.StringValue("example"),
+				Id:         types.StringValue("00:aa:bb:cc:dd:ee"),
+			},
+		},
+		"missing host": {
+			macAddress:   "00:11:22:33:44:55",
+			expectErrors: []string{"Unable to read DHCP Static Host"},
+			expectDetail: "not found",
+		},
+		"diverging replicas": {
+			err: &client.ReplicaDivergenceError{
+				MacAddress: "00:11:22:33:44:55",
+				Endpoints:  []string{"http://replica-1:6904", "http://replica-2:6904"},
+				Hosts:      []*client.StaticDhcpHost{&testExistingHost, nil},
+				Host:       &testExistingHost,
+			},
+			macAddress: "00:11:22:33:44:55",
+			expectState: &DhcpStaticHostDataSourceModel{
+				MacAddress: types.StringValue("00:11:22:33:44:55"),
+				IPAddress:  types.StringValue("10.0.0.5"),
+				HostName:   types.StringValue("example"),
+				Id:         types.StringValue("00:11:22:33:44:55"),
+			},
+			expectWarnings: []string{"DHCP Static Host differs between dnsmasq servers"},
+			expectDetail:   "the reservation of `api_url` is used",
+		},
+		"host missing from the primary replica": {
+			err: &client.ReplicaDivergenceError{
+				MacAddress: "00:11:22:33:44:55",
+				Endpoints:  []string{"http://replica-1:6904", "http://replica-2:6904"},
+				Hosts:      []*client.StaticDhcpHost{nil, &testExistingHost},
+			},
+			macAddress:   "00:11:22:33:44:55",
+			expectErrors: []string{"Unable to read DHCP Static Host"},
+			expectDetail: "differs between dnsmasq-manager replicas",
+		},
+		"replica failures": {
+			err:          testReplicationError(),
+			macAddress:   "00:11:22:33:44:55",
+			expectErrors: []string{"Unable to read DHCP Static Host", "Unable to read DHCP Static Host"},
+			expectDetail: "The request failed on the dnsmasq-manager replica at http://replica-",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dnsmasq := newMemoryClient(test.hosts...)
+			dnsmasq.err = test.err
+			d := &DhcpStaticHostDataSource{client: dnsmasq}
+
+			schemaResp := datasource.SchemaResponse{}
+			d.Schema(context.Background(), datasource.SchemaRequest{}, &schemaResp)
+			objectType := schemaResp.Schema.Type().TerraformType(context.Background())
+
+			config := tfsdk.Config{
+				Schema: schemaResp.Schema,
+				Raw: tftypes.NewValue(objectType, map[string]tftypes.Value{
+					"mac_address": tftypes.NewValue(tftypes.String, test.macAddress),
+					"ip_address":  tftypes.NewValue(tftypes.String, nil),
+					"hostname":    tftypes.NewValue(tftypes.String, nil),
+					"id":          tftypes.NewValue(tftypes.String, nil),
+				}),
+			}
+			resp := datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}}
+			d.Read(context.Background(), datasource.ReadRequest{Config: config}, &resp)
+
+			checkDiagnostics(t, resp.Diagnostics, test.expectErrors, test.expectWarnings, test.expectDetail)
+			if test.expectState == nil {
+				if !resp.State.Raw.IsNull() {
+					t.Errorf("expected no state, got %v", resp.State.Raw)
+				}
+				return
+			}
+
+			var data DhcpStaticHostDataSourceModel
+			resp.Diagnostics.Append(resp.State.Get(context.Background(), &data)...)
+			if data != *test.expectState {
+				t.Errorf("expected state %+v, got %+v", *test.expectState, data)
+			}
+		})
+	}
+}
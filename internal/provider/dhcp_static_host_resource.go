@@ -2,10 +2,12 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"terraform-provider-dnsmasq/internal/client"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -37,6 +39,7 @@ type DhcpStaticHostResourceModel struct {
 	IPAddress  types.String `tfsdk:"ip_address"`
 	HostName   types.String `tfsdk:"hostname"`
 	Id         types.String `tfsdk:"id"`
+	Revision   types.String `tfsdk:"revision"`
 }
 
 func (m *DhcpStaticHostResourceModel) toDnsmasq() client.StaticDhcpHost {
@@ -44,6 +47,7 @@ func (m *DhcpStaticHostResourceModel) toDnsmasq() client.StaticDhcpHost {
 		MacAddress: m.MacAddress.ValueString(),
 		IPAddress:  m.IPAddress.ValueString(),
 		HostName:   m.HostName.ValueString(),
+		Revision:   m.Revision.ValueString(),
 	}
 }
 
@@ -54,6 +58,11 @@ func (m *DhcpStaticHostResourceModel) fromDnsmasq(host *client.StaticDhcpHost) {
 	m.IPAddress = types.StringValue(host.IPAddress)
 	m.HostName = types.StringValue(host.HostName)
 	m.Id = types.StringValue(host.MacAddress)
+	if host.Revision != "" {
+		m.Revision = types.StringValue(host.Revision)
+	} else {
+		m.Revision = types.StringNull()
+	}
 }
 
 func (r *DhcpStaticHostResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -87,6 +96,10 @@ func (r *DhcpStaticHostResource) Schema(ctx context.Context, req resource.Schema
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"revision": schema.StringAttribute{
+				MarkdownDescription: "Revision (ETag) of the reservation on the server. Updates and deletes are rejected when the reservation was modified outside of Terraform since it was last read. Empty when the server does not support revisions.",
+				Computed:            true,
+			},
 		},
 	}
 }
@@ -97,7 +110,7 @@ func (r *DhcpStaticHostResource) Configure(ctx context.Context, req resource.Con
 		return
 	}
 
-	client, ok := req.ProviderData.(client.Client)
+	dnsmasq, ok := req.ProviderData.(client.Client)
 
 	if !ok {
 		resp.Diagnostics.AddError(
@@ -108,7 +121,8 @@ func (r *DhcpStaticHostResource) Configure(ctx context.Context, req resource.Con
 		return
 	}
 
-	r.client = client
+	resp.Diagnostics.Append(checkServerFeature(dnsmasq, client.FeatureStaticHosts, "dnsmasq_dhcp_static_host")...)
+	r.client = dnsmasq
 }
 
 func (r *DhcpStaticHostResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -120,9 +134,13 @@ func (r *DhcpStaticHostResource) Create(ctx context.Context, req resource.Create
 		return
 	}
 
-	host, err := r.client.CreateStaticDhcpHost(data.toDnsmasq())
+	host, err := r.client.CreateStaticDhcpHost(ctx, data.toDnsmasq())
 	if err != nil {
-		resp.Diagnostics.AddError("Unable to create DHCP Static Host", err.Error())
+		addClientError(&resp.Diagnostics, "Unable to create DHCP Static Host", err)
+		return
+	}
+	if host == nil {
+		addMissingHostError(&resp.Diagnostics, "Unable to create DHCP Static Host")
 		return
 	}
 
@@ -142,9 +160,40 @@ func (r *DhcpStaticHostResource) Read(ctx context.Context, req resource.ReadRequ
 		return
 	}
 
-	host, err := r.client.ReadStaticDhcpHost(state.Id.ValueString())
+	host, err := r.client.ReadStaticDhcpHost(ctx, state.Id.ValueString())
+	var notFound *client.NotFoundError
+	if errors.As(err, &notFound) {
+		// The reservation was removed outside of Terraform, drop it from the
+		// state so Terraform plans to re-create it.
+		tflog.Warn(ctx, "DHCP static host not found, removing from state", map[string]interface{}{"mac_address": state.Id.ValueString()})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	var divergence *client.ReplicaDivergenceError
+	if errors.As(err, &divergence) {
+		if divergence.Host == nil {
+			// The primary server does not have the reservation, drop it from
+			// the state so Terraform plans to create it again, which brings
+			// every replica in line.
+			resp.Diagnostics.AddWarning(
+				"DHCP Static Host differs between dnsmasq servers",
+				"The reservation is missing from `api_url`, it was removed from the state and will be written to every replica on the next apply.\n\n"+
+					err.Error(),
+			)
+			resp.State.RemoveResource(ctx)
+			return
+		}
+
+		// Keep the reservation of the primary server in the state.
+		addReplicaDivergenceWarning(&resp.Diagnostics, divergence)
+		host, err = divergence.Host, nil
+	}
 	if err != nil {
-		resp.Diagnostics.AddError("Unable to read DHCP Static Host", err.Error())
+		addClientError(&resp.Diagnostics, "Unable to read DHCP Static Host", err)
+		return
+	}
+	if host == nil {
+		addMissingHostError(&resp.Diagnostics, "Unable to read DHCP Static Host")
 		return
 	}
 
@@ -160,13 +209,28 @@ func (r *DhcpStaticHostResource) Update(ctx context.Context, req resource.Update
 	var data DhcpStaticHostResourceModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
 
+	// The planned revision is unknown, the update is conditioned on the
+	// revision last read into the state.
+	var state DhcpStaticHostResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	host, err := r.client.UpdateStaticDhcpHost(data.toDnsmasq())
+	data.Revision = state.Revision
+	host, err := r.client.UpdateStaticDhcpHost(ctx, data.toDnsmasq())
+	var preconditionFailed *client.PreconditionFailedError
+	if errors.As(err, &preconditionFailed) {
+		addModifiedOutsideTerraformError(&resp.Diagnostics, "update", err)
+		return
+	}
 	if err != nil {
-		resp.Diagnostics.AddError("Unable to update DHCP Static Host", err.Error())
+		addClientError(&resp.Diagnostics, "Unable to update DHCP Static Host", err)
+		return
+	}
+	if host == nil {
+		addMissingHostError(&resp.Diagnostics, "Unable to update DHCP Static Host")
 		return
 	}
 
@@ -186,15 +250,76 @@ func (r *DhcpStaticHostResource) Delete(ctx context.Context, req resource.Delete
 		return
 	}
 
-	_, err := r.client.DeleteStaticDhcpHost(state.Id.ValueString())
+	_, err := r.client.DeleteStaticDhcpHost(ctx, state.Id.ValueString(), state.Revision.ValueString())
+	var notFound *client.NotFoundError
+	if errors.As(err, &notFound) {
+		// The reservation is already gone, which is the desired outcome.
+		tflog.Trace(ctx, "DHCP static host already deleted")
+		return
+	}
+	var preconditionFailed *client.PreconditionFailedError
+	if errors.As(err, &preconditionFailed) {
+		addModifiedOutsideTerraformError(&resp.Diagnostics, "delete", err)
+		return
+	}
 	if err != nil {
-		resp.Diagnostics.AddError("Unable to delete DHCP Static Host", err.Error())
+		addClientError(&resp.Diagnostics, "Unable to delete DHCP Static Host", err)
 		return
 	}
 
 	tflog.Trace(ctx, "deleted a DHCP static host resource")
 }
 
+// addModifiedOutsideTerraformError reports an update or delete rejected
+// because the reservation revision changed since it was last read.
+func addModifiedOutsideTerraformError(diags *diag.Diagnostics, operation string, err error) {
+	diags.AddError(
+		fmt.Sprintf("Unable to %s DHCP Static Host", operation),
+		"The reservation was modified outside Terraform since it was last read, refresh and retry. "+
+			"Run a new plan to review the changes made by someone else before applying yours.\n\n"+
+			err.Error(),
+	)
+}
+
+// addMissingHostError reports a client call that succeeded without returning
+// the host, which cannot be saved into the state.
+func addMissingHostError(diags *diag.Diagnostics, summary string) {
+	diags.AddError(
+		summary,
+		"dnsmasq-manager did not return the DHCP Static Host. Please report this issue to the provider developers.",
+	)
+}
+
+// addClientError reports a failed client call. A write that failed on some
+// dnsmasq-manager replicas is reported with a diagnostic per failed replica.
+func addClientError(diags *diag.Diagnostics, summary string, err error) {
+	var replicationErr *client.ReplicationError
+	if !errors.As(err, &replicationErr) {
+		diags.AddError(summary, err.Error())
+		return
+	}
+
+	for _, replicaErr := range replicationErr.Errors {
+		diags.AddError(
+			summary,
+			fmt.Sprintf("The request failed on the dnsmasq-manager replica at %s. ", replicaErr.Endpoint)+
+				"Changes may have been applied to the other replicas, apply again to bring every replica in line.\n\n"+
+				replicaErr.Err.Error(),
+		)
+	}
+}
+
+// addReplicaDivergenceWarning reports a reservation that differs between the
+// dnsmasq-manager replicas, of which the reservation of the primary is used.
+func addReplicaDivergenceWarning(diags *diag.Diagnostics, divergence *client.ReplicaDivergenceError) {
+	diags.AddWarning(
+		"DHCP Static Host differs between dnsmasq servers",
+		"The reservation is not the same on every dnsmasq-manager replica, the reservation of `api_url` is used. "+
+			"Replace the `dnsmasq_dhcp_static_host` resource managing it, for example with `terraform apply -replace`, to write it to every replica again.\n\n"+
+			divergence.Error(),
+	)
+}
+
 func (r *DhcpStaticHostResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
@@ -1,10 +1,20 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"reflect"
+	"strings"
+	"terraform-provider-dnsmasq/internal/client"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 )
 
 func TestAccDhcpStaticHostResource(t *testing.T) {
@@ -42,6 +52,35 @@ func TestAccDhcpStaticHostResource(t *testing.T) {
 	})
 }
 
+func TestAccDhcpStaticHostResource_disappears(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Removing the reservation outside of Terraform must plan a re-create
+			{
+				Config: testAccDhcpStaticHostResourceConfig("00:11:22:33:44:66", "1.2.3.5", "disappears"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dnsmasq_dhcp_static_host.test", "mac_address", "00:11:22:33:44:66"),
+					testAccDeleteDhcpStaticHost("00:11:22:33:44:66"),
+				),
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func testAccDeleteDhcpStaticHost(macAddress string) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		dnsmasq, err := testAccClient()
+		if err != nil {
+			return err
+		}
+		_, err = dnsmasq.DeleteStaticDhcpHost(context.Background(), macAddress, "")
+		return err
+	}
+}
+
 func testAccDhcpStaticHostResourceConfig(macAddress string, ipAddress string, hostName string) string {
 	return providerConfig + fmt.Sprintf(`
 resource "dnsmasq_dhcp_static_host" "test" {
@@ -51,3 +90,495 @@ resource "dnsmasq_dhcp_static_host" "test" {
 }
 `, macAddress, ipAddress, hostName)
 }
+
+// testHost returns a resource model, leaving the attributes set to an empty
+// string null.
+func testHost(macAddress string, ipAddress string, hostName string, id string, revision string) DhcpStaticHostResourceModel {
+	return DhcpStaticHostResourceModel{
+		MacAddress: types.StringPointerValue(nonEmpty(macAddress)),
+		IPAddress:  types.StringPointerValue(nonEmpty(ipAddress)),
+		HostName:   types.StringPointerValue(nonEmpty(hostName)),
+		Id:         types.StringPointerValue(nonEmpty(id)),
+		Revision:   types.StringPointerValue(nonEmpty(revision)),
+	}
+}
+
+func testHostState(macAddress string, ipAddress string, hostName string, id string, revision string) *DhcpStaticHostResourceModel {
+	host := testHost(macAddress, ipAddress, hostName, id, revision)
+	return &host
+}
+
+// testPlannedHost returns the model planned for a new host, with the
+// computed attributes unknown.
+func testPlannedHost(macAddress string, ipAddress string, hostName string) DhcpStaticHostResourceModel {
+	host := testHost(macAddress, ipAddress, hostName, "", "")
+	host.Id = types.StringUnknown()
+	host.Revision = types.StringUnknown()
+	return host
+}
+
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// testResourceState returns the state of r holding data, or a null state
+// when data is nil.
+func testResourceState(t *testing.T, r fwresource.Resource, data *DhcpStaticHostResourceModel) tfsdk.State {
+	t.Helper()
+
+	schemaResp := fwresource.SchemaResponse{}
+	r.Schema(context.Background(), fwresource.SchemaRequest{}, &schemaResp)
+
+	state := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(context.Background()), nil),
+	}
+	if data != nil {
+		if diags := state.Set(context.Background(), data); diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+	}
+	return state
+}
+
+func testResourcePlan(t *testing.T, r fwresource.Resource, data DhcpStaticHostResourceModel) tfsdk.Plan {
+	t.Helper()
+
+	state := testResourceState(t, r, &data)
+	return tfsdk.Plan{Schema: state.Schema, Raw: state.Raw}
+}
+
+// checkResourceState fails the test when state does not hold expected, or is
+// not null when expected is nil.
+func checkResourceState(t *testing.T, state tfsdk.State, expected *DhcpStaticHostResourceModel) {
+	t.Helper()
+
+	if expected == nil {
+		if !state.Raw.IsNull() {
+			t.Errorf("expected the resource to be removed from state, got %v", state.Raw)
+		}
+		return
+	}
+
+	var data DhcpStaticHostResourceModel
+	if diags := state.Get(context.Background(), &data); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if data != *expected {
+		t.Errorf("expected state %+v, got %+v", *expected, data)
+	}
+}
+
+// checkDiagnostics fails the test unless diags holds exactly the expected
+// error and warning summaries, in order, with every detail containing
+// expectDetail.
+func checkDiagnostics(t *testing.T, diags diag.Diagnostics, expectErrors []string, expectWarnings []string, expectDetail string) {
+	t.Helper()
+
+	summaries := func(diags diag.Diagnostics) []string {
+		var summaries []string
+		for _, d := range diags {
+			summaries = append(summaries, d.Summary())
+			if !strings.Contains(d.Detail(), expectDetail) {
+				t.Errorf("expected diagnostic detail containing %q, got %q", expectDetail, d.Detail())
+			}
+		}
+		return summaries
+	}
+
+	if errors := summaries(diags.Errors()); strings.Join(errors, "\n") != strings.Join(expectErrors, "\n") {
+		t.Errorf("expected errors %q, got %q", expectErrors, errors)
+	}
+	if warnings := summaries(diags.Warnings()); strings.Join(warnings, "\n") != strings.Join(expectWarnings, "\n") {
+		t.Errorf("expected warnings %q, got %q", expectWarnings, warnings)
+	}
+}
+
+func testServerError() error {
+	return &client.ServerError{APIError: memoryAPIError(500, "GET", "Internal Server Error")}
+}
+
+func testReplicationError() error {
+	return &client.ReplicationError{
+		Replicas: 3,
+		Errors: []*client.ReplicaError{
+			{Endpoint: "http://replica-1:6904", Err: testServerError()},
+			{Endpoint: "http://replica-2:6904", Err: testServerError()},
+		},
+	}
+}
+
+var testExistingHost = client.StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.5", HostName: "example"}
+
+func TestDhcpStaticHostResourceConfigure(t *testing.T) {
+	tests := map[string]struct {
+		providerData any
+		expectErrors []string
+	}{
+		"unconfigured provider": {},
+		"client": {
+			providerData: newMemoryClient(),
+		},
+		"unexpected type": {
+			providerData: "client",
+			expectErrors: []string{"Unexpected Resource Configure Type"},
+		},
+		"server without static hosts": {
+			providerData: &memoryClient{info: &client.ServerInfo{APIVersion: "1.0", ManagerVersion: "0.0.1"}},
+			expectErrors: []string{"Unsupported dnsmasq-manager version"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := &DhcpStaticHostResource{}
+			resp := fwresource.ConfigureResponse{}
+			r.Configure(context.Background(), fwresource.ConfigureRequest{ProviderData: test.providerData}, &resp)
+
+			checkDiagnostics(t, resp.Diagnostics, test.expectErrors, nil, "")
+		})
+	}
+}
+
+func TestDhcpStaticHostResourceCreate(t *testing.T) {
+	tests := map[string]struct {
+		hosts        []client.StaticDhcpHost
+		noRevisions  bool
+		omitHost     bool
+		err          error
+		plan         DhcpStaticHostResourceModel
+		expectState  *DhcpStaticHostResourceModel
+		expectErrors []string
+		expectDetail string
+	}{
+		"new host": {
+			plan:        testPlannedHost("00:11:22:33:44:55", "10.0.0.5", "example"),
+			expectState: testHostState("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+		},
+		"uppercase MAC address": {
+			plan:        testPlannedHost("00:AA:BB:CC:DD:EE", "10.0.0.5", "example"),
+			expectState: testHostState("00:AA:BB:CC:DD:EE", "10.0.0.5", "example", "00:aa:bb:cc:dd:ee", `"1"`),
+		},
+		"server without revisions": {
+			noRevisions: true,
+			plan:        testPlannedHost("00:11:22:33:44:55", "10.0.0.5", "example"),
+			expectState: testHostState("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", ""),
+		},
+		"existing host": {
+			hosts:        []client.StaticDhcpHost{testExistingHost},
+			plan:         testPlannedHost("00:11:22:33:44:55", "10.0.0.6", "other"),
+			expectErrors: []string{"Unable to create DHCP Static Host"},
+			expectDetail: "already exists",
+		},
+		"no host returned": {
+			omitHost:     true,
+			plan:         testPlannedHost("00:11:22:33:44:55", "10.0.0.5", "example"),
+			expectErrors: []string{"Unable to create DHCP Static Host"},
+			expectDetail: "did not return the DHCP Static Host",
+		},
+		"server error": {
+			err:          testServerError(),
+			plan:         testPlannedHost("00:11:22:33:44:55", "10.0.0.5", "example"),
+			expectErrors: []string{"Unable to create DHCP Static Host"},
+			expectDetail: "500 Internal Server Error",
+		},
+		"replica failures": {
+			err:          testReplicationError(),
+			plan:         testPlannedHost("00:11:22:33:44:55", "10.0.0.5", "example"),
+			expectErrors: []string{"Unable to create DHCP Static Host", "Unable to create DHCP Static Host"},
+			expectDetail: "The request failed on the dnsmasq-manager replica at http://replica-",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dnsmasq := newMemoryClient(test.hosts...)
+			dnsmasq.revisions = !test.noRevisions
+			dnsmasq.omitHost = test.omitHost
+			dnsmasq.err = test.err
+			r := &DhcpStaticHostResource{client: dnsmasq}
+
+			resp := fwresource.CreateResponse{State: testResourceState(t, r, nil)}
+			r.Create(context.Background(), fwresource.CreateRequest{Plan: testResourcePlan(t, r, test.plan)}, &resp)
+
+			checkDiagnostics(t, resp.Diagnostics, test.expectErrors, nil, test.expectDetail)
+			checkResourceState(t, resp.State, test.expectState)
+		})
+	}
+}
+
+func TestDhcpStaticHostResourceRead(t *testing.T) {
+	tests := map[string]struct {
+		hosts          []client.StaticDhcpHost
+		noRevisions    bool
+		omitHost       bool
+		err            error
+		state          DhcpStaticHostResourceModel
+		expectState    *DhcpStaticHostResourceModel
+		expectErrors   []string
+		expectWarnings []string
+		expectDetail   string
+	}{
+		"unchanged host": {
+			hosts:       []client.StaticDhcpHost{testExistingHost},
+			state:       testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+			expectState: testHostState("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+		},
+		"host modified outside terraform": {
+			hosts:       []client.StaticDhcpHost{testExistingHost},
+			state:       testHost("00:11:22:33:44:55", "10.0.0.4", "old", "00:11:22:33:44:55", `"0"`),
+			expectState: testHostState("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+		},
+		"uppercase MAC address": {
+			hosts:       []client.StaticDhcpHost{{MacAddress: "00:AA:BB:CC:DD:EE", IPAddress: "10.0.0.5", HostName: "example"}},
+			state:       testHost("00:AA:BB:CC:DD:EE", "10.0.0.5", "example", "00:aa:bb:cc:dd:ee", `"1"`),
+			expectState: testHostState("00:AA:BB:CC:DD:EE", "10.0.0.5", "example", "00:aa:bb:cc:dd:ee", `"1"`),
+		},
+		"imported host": {
+			hosts:       []client.StaticDhcpHost{testExistingHost},
+			state:       testHost("", "", "", "00:11:22:33:44:55", ""),
+			expectState: testHostState("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+		},
+		"server without revisions": {
+			hosts:       []client.StaticDhcpHost{testExistingHost},
+			noRevisions: true,
+			state:       testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", ""),
+			expectState: testHostState("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", ""),
+		},
+		"host deleted outside terraform": {
+			state: testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+		},
+		"no host returned": {
+			hosts:        []client.StaticDhcpHost{testExistingHost},
+			omitHost:     true,
+			state:        testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+			expectState:  testHostState("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+			expectErrors: []string{"Unable to read DHCP Static Host"},
+			expectDetail: "did not return the DHCP Static Host",
+		},
+		"diverging replicas": {
+			err: &client.ReplicaDivergenceError{
+				MacAddress: "00:11:22:33:44:55",
+				Endpoints:  []string{"http://replica-1:6904", "http://replica-2:6904"},
+				Hosts:      []*client.StaticDhcpHost{&testExistingHost, nil},
+				Host:       &client.StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.5", HostName: "example", Revision: `"1" `},
+			},
+			state:          testHost("00:11:22:33:44:55", "10.0.0.4", "old", "00:11:22:33:44:55", `"1"`),
+			expectState:    testHostState("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1" `),
+			expectWarnings: []string{"DHCP Static Host differs between dnsmasq servers"},
+			expectDetail:   "the reservation of `api_url` is used",
+		},
+		"host missing from the primary replica": {
+			err: &client.ReplicaDivergenceError{
+				MacAddress: "00:11:22:33:44:55",
+				Endpoints:  []string{"http://replica-1:6904", "http://replica-2:6904"},
+				Hosts:      []*client.StaticDhcpHost{nil, &testExistingHost},
+			},
+			state:          testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+			expectWarnings: []string{"DHCP Static Host differs between dnsmasq servers"},
+			expectDetail:   "written to every replica on the next apply",
+		},
+		"server error": {
+			err:          testServerError(),
+			state:        testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+			expectState:  testHostState("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+			expectErrors: []string{"Unable to read DHCP Static Host"},
+			expectDetail: "500 Internal Server Error",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dnsmasq := newMemoryClient()
+			dnsmasq.revisions = !test.noRevisions
+			dnsmasq.omitHost = test.omitHost
+			for _, host := range test.hosts {
+				dnsmasq.store(host)
+			}
+			dnsmasq.err = test.err
+			r := &DhcpStaticHostResource{client: dnsmasq}
+
+			state := testResourceState(t, r, &test.state)
+			resp := fwresource.ReadResponse{State: state}
+			r.Read(context.Background(), fwresource.ReadRequest{State: state}, &resp)
+
+			checkDiagnostics(t, resp.Diagnostics, test.expectErrors, test.expectWarnings, test.expectDetail)
+			checkResourceState(t, resp.State, test.expectState)
+		})
+	}
+}
+
+func TestDhcpStaticHostResourceUpdate(t *testing.T) {
+	tests := map[string]struct {
+		hosts        []client.StaticDhcpHost
+		noRevisions  bool
+		omitHost     bool
+		err          error
+		state        DhcpStaticHostResourceModel
+		plan         DhcpStaticHostResourceModel
+		expectState  *DhcpStaticHostResourceModel
+		expectHost   *client.StaticDhcpHost
+		expectErrors []string
+		expectDetail string
+	}{
+		"changed host": {
+			hosts:       []client.StaticDhcpHost{testExistingHost},
+			state:       testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+			plan:        testHost("00:11:22:33:44:55", "10.0.0.6", "other", "00:11:22:33:44:55", ""),
+			expectState: testHostState("00:11:22:33:44:55", "10.0.0.6", "other", "00:11:22:33:44:55", `"2"`),
+			expectHost:  &client.StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.6", HostName: "other", Revision: `"2"`},
+		},
+		"uppercase MAC address": {
+			hosts:       []client.StaticDhcpHost{{MacAddress: "00:AA:BB:CC:DD:EE", IPAddress: "10.0.0.5", HostName: "example"}},
+			state:       testHost("00:AA:BB:CC:DD:EE", "10.0.0.5", "example", "00:aa:bb:cc:dd:ee", `"1"`),
+			plan:        testHost("00:AA:BB:CC:DD:EE", "10.0.0.6", "example", "00:aa:bb:cc:dd:ee", ""),
+			expectState: testHostState("00:AA:BB:CC:DD:EE", "10.0.0.6", "example", "00:aa:bb:cc:dd:ee", `"2"`),
+		},
+		"server without revisions": {
+			hosts:       []client.StaticDhcpHost{testExistingHost},
+			noRevisions: true,
+			state:       testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", ""),
+			plan:        testHost("00:11:22:33:44:55", "10.0.0.6", "other", "00:11:22:33:44:55", ""),
+			expectState: testHostState("00:11:22:33:44:55", "10.0.0.6", "other", "00:11:22:33:44:55", ""),
+		},
+		"host modified outside terraform": {
+			hosts:        []client.StaticDhcpHost{testExistingHost},
+			state:        testHost("00:11:22:33:44:55", "10.0.0.4", "old", "00:11:22:33:44:55", `"0"`),
+			plan:         testHost("00:11:22:33:44:55", "10.0.0.6", "other", "00:11:22:33:44:55", ""),
+			expectState:  testHostState("00:11:22:33:44:55", "10.0.0.6", "other", "00:11:22:33:44:55", ""),
+			expectHost:   &client.StaticDhcpHost{MacAddress: "00:11:22:33:44:55", IPAddress: "10.0.0.5", HostName: "example", Revision: `"1"`},
+			expectErrors: []string{"Unable to update DHCP Static Host"},
+			expectDetail: "modified outside Terraform",
+		},
+		"host deleted outside terraform": {
+			state:        testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+			plan:         testHost("00:11:22:33:44:55", "10.0.0.6", "other", "00:11:22:33:44:55", ""),
+			expectState:  testHostState("00:11:22:33:44:55", "10.0.0.6", "other", "00:11:22:33:44:55", ""),
+			expectErrors: []string{"Unable to update DHCP Static Host"},
+			expectDetail: "not found",
+		},
+		"no host returned": {
+			hosts:        []client.StaticDhcpHost{testExistingHost},
+			omitHost:     true,
+			state:        testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+			plan:         testHost("00:11:22:33:44:55", "10.0.0.6", "other", "00:11:22:33:44:55", ""),
+			expectState:  testHostState("00:11:22:33:44:55", "10.0.0.6", "other", "00:11:22:33:44:55", ""),
+			expectErrors: []string{"Unable to update DHCP Static Host"},
+			expectDetail: "did not return the DHCP Static Host",
+		},
+		"replica failures": {
+			err:          testReplicationError(),
+			state:        testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+			plan:         testHost("00:11:22:33:44:55", "10.0.0.6", "other", "00:11:22:33:44:55", ""),
+			expectState:  testHostState("00:11:22:33:44:55", "10.0.0.6", "other", "00:11:22:33:44:55", ""),
+			expectErrors: []string{"Unable to update DHCP Static Host", "Unable to update DHCP Static Host"},
+			expectDetail: "apply again to bring every replica in line",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dnsmasq := newMemoryClient()
+			dnsmasq.revisions = !test.noRevisions
+			dnsmasq.omitHost = test.omitHost
+			for _, host := range test.hosts {
+				dnsmasq.store(host)
+			}
+			dnsmasq.err = test.err
+			r := &DhcpStaticHostResource{client: dnsmasq}
+
+			// The framework initializes the new state from the plan.
+			plan := testResourcePlan(t, r, test.plan)
+			resp := fwresource.UpdateResponse{State: tfsdk.State{Schema: plan.Schema, Raw: plan.Raw}}
+			r.Update(context.Background(), fwresource.UpdateRequest{
+				Plan:  plan,
+				State: testResourceState(t, r, &test.state),
+			}, &resp)
+
+			checkDiagnostics(t, resp.Diagnostics, test.expectErrors, nil, test.expectDetail)
+			checkResourceState(t, resp.State, test.expectState)
+			if test.expectHost != nil {
+				host, _ := dnsmasq.host(test.expectHost.MacAddress)
+				if !reflect.DeepEqual(host, *test.expectHost) {
+					t.Errorf("expected host %+v on the server, got %+v", *test.expectHost, host)
+				}
+			}
+		})
+	}
+}
+
+func TestDhcpStaticHostResourceDelete(t *testing.T) {
+	tests := map[string]struct {
+		hosts        []client.StaticDhcpHost
+		err          error
+		state        DhcpStaticHostResourceModel
+		expectExists bool
+		expectErrors []string
+		expectDetail string
+	}{
+		"existing host": {
+			hosts: []client.StaticDhcpHost{testExistingHost},
+			state: testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+		},
+		"uppercase MAC address": {
+			hosts: []client.StaticDhcpHost{{MacAddress: "00:AA:BB:CC:DD:EE", IPAddress: "10.0.0.5", HostName: "example"}},
+			state: testHost("00:AA:BB:CC:DD:EE", "10.0.0.5", "example", "00:aa:bb:cc:dd:ee", `"1"`),
+		},
+		"host deleted outside terraform": {
+			state: testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+		},
+		"host modified outside terraform": {
+			hosts:        []client.StaticDhcpHost{testExistingHost},
+			state:        testHost("00:11:22:33:44:55", "10.0.0.4", "old", "00:11:22:33:44:55", `"0"`),
+			expectExists: true,
+			expectErrors: []string{"Unable to delete DHCP Static Host"},
+			expectDetail: "modified outside Terraform",
+		},
+		"server error": {
+			hosts:        []client.StaticDhcpHost{testExistingHost},
+			err:          testServerError(),
+			state:        testHost("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`),
+			expectExists: true,
+			expectErrors: []string{"Unable to delete DHCP Static Host"},
+			expectDetail: "500 Internal Server Error",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dnsmasq := newMemoryClient(test.hosts...)
+			dnsmasq.err = test.err
+			r := &DhcpStaticHostResource{client: dnsmasq}
+
+			state := testResourceState(t, r, &test.state)
+			resp := fwresource.DeleteResponse{State: state}
+			r.Delete(context.Background(), fwresource.DeleteRequest{State: state}, &resp)
+
+			checkDiagnostics(t, resp.Diagnostics, test.expectErrors, nil, test.expectDetail)
+			if _, exists := dnsmasq.host(test.state.Id.ValueString()); exists != test.expectExists {
+				t.Errorf("expected the host to exist on the server: %t, got %t", test.expectExists, exists)
+			}
+		})
+	}
+}
+
+func TestDhcpStaticHostResourceImportState(t *testing.T) {
+	dnsmasq := newMemoryClient(testExistingHost)
+	r := &DhcpStaticHostResource{client: dnsmasq}
+
+	importResp := fwresource.ImportStateResponse{State: testResourceState(t, r, nil)}
+	r.ImportState(context.Background(), fwresource.ImportStateRequest{ID: "00:11:22:33:44:55"}, &importResp)
+
+	checkDiagnostics(t, importResp.Diagnostics, nil, nil, "")
+	checkResourceState(t, importResp.State, testHostState("", "", "", "00:11:22:33:44:55", ""))
+
+	// The framework reads the imported resource right away.
+	readResp := fwresource.ReadResponse{State: importResp.State}
+	r.Read(context.Background(), fwresource.ReadRequest{State: importResp.State}, &readResp)
+
+	checkDiagnostics(t, readResp.Diagnostics, nil, nil, "")
+	checkResourceState(t, readResp.State, testHostState("00:11:22:33:44:55", "10.0.0.5", "example", "00:11:22:33:44:55", `"1"`))
+}
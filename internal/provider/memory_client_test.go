@@ -0,0 +1,219 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"terraform-provider-dnsmasq/internal/client"
+)
+
+// memoryClient is an in-memory client.Client for unit tests of the resources
+// and data sources, behaving like a dnsmasq-manager that stores MAC addresses
+// in lowercase.
+type memoryClient struct {
+	mu        sync.Mutex
+	hosts     map[string]client.StaticDhcpHost
+	revision  int
+	info      *client.ServerInfo
+	revisions bool
+
+	// err, when set, is returned by every call instead of the outcome of
+	// the operation.
+	err error
+
+	// omitHost makes the operations succeed without returning the host.
+	omitHost bool
+}
+
+var _ client.Client = &memoryClient{}
+
+func newMemoryClient(hosts ...client.StaticDhcpHost) *memoryClient {
+	c := &memoryClient{hosts: map[string]client.StaticDhcpHost{}, revisions: true}
+	for _, host := range hosts {
+		c.store(host)
+	}
+	return c
+}
+
+// store saves the host under a new revision, and returns it as saved.
+func (c *memoryClient) store(host client.StaticDhcpHost) *client.StaticDhcpHost {
+	host.MacAddress = strings.ToLower(host.MacAddress)
+	host.Revision = ""
+	if c.revisions {
+		c.revision++
+		host.Revision = fmt.Sprintf(`"%d"`, c.revision)
+	}
+	c.hosts[host.MacAddress] = host
+	return &host
+}
+
+// host returns the stored host with the given MAC address.
+func (c *memoryClient) host(macAddress string) (client.StaticDhcpHost, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	host, ok := c.hosts[strings.ToLower(macAddress)]
+	return host, ok
+}
+
+func (c *memoryClient) CreateStaticDhcpHost(ctx context.Context, host client.StaticDhcpHost) (*client.StaticDhcpHost, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	if _, ok := c.hosts[strings.ToLower(host.MacAddress)]; ok {
+		return nil, &client.ConflictError{APIError: memoryAPIError(http.StatusConflict, http.MethodPost, "Static DHCP host already exists")}
+	}
+	return c.result(c.store(host)), nil
+}
+
+func (c *memoryClient) ReadStaticDhcpHost(ctx context.Context, macAddress string) (*client.StaticDhcpHost, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	host, ok := c.hosts[strings.ToLower(macAddress)]
+	if !ok {
+		return nil, &client.NotFoundError{APIError: memoryAPIError(http.StatusNotFound, http.MethodGet, "Static DHCP host not found")}
+	}
+	return c.result(&host), nil
+}
+
+func (c *memoryClient) UpdateStaticDhcpHost(ctx context.Context, host client.StaticDhcpHost) (*client.StaticDhcpHost, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	if err := c.checkRevision(http.MethodPut, host.MacAddress, host.Revision); err != nil {
+		return nil, err
+	}
+	return c.result(c.store(host)), nil
+}
+
+func (c *memoryClient) DeleteStaticDhcpHost(ctx context.Context, macAddress string, revision string) (*client.StaticDhcpHost, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	if err := c.checkRevision(http.MethodDelete, macAddress, revision); err != nil {
+		return nil, err
+	}
+	host := c.hosts[strings.ToLower(macAddress)]
+	delete(c.hosts, strings.ToLower(macAddress))
+	return c.result(&host), nil
+}
+
+// result returns the host returned by an operation.
+func (c *memoryClient) result(host *client.StaticDhcpHost) *client.StaticDhcpHost {
+	if c.omitHost {
+		return nil
+	}
+	return host
+}
+
+// checkRevision fails like dnsmasq-manager when the host does not exist or
+// was modified since the given revision was read.
+func (c *memoryClient) checkRevision(method string, macAddress string, revision string) error {
+	host, ok := c.hosts[strings.ToLower(macAddress)]
+	if !ok {
+		return &client.NotFoundError{APIError: memoryAPIError(http.StatusNotFound, method, "Static DHCP host not found")}
+	}
+	if revision != "" && revision != host.Revision {
+		return &client.PreconditionFailedError{APIError: memoryAPIError(http.StatusPreconditionFailed, method, "Static DHCP host was modified")}
+	}
+	return nil
+}
+
+func (c *memoryClient) ListStaticDhcpHosts(ctx context.Context, filter client.StaticDhcpHostFilter) ([]client.StaticDhcpHost, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	var network *net.IPNet
+	if filter.CIDR != "" {
+		var err error
+		if _, network, err = net.ParseCIDR(filter.CIDR); err != nil {
+			return nil, &client.ValidationError{APIError: memoryAPIError(http.StatusBadRequest, http.MethodGet, "Invalid CIDR")}
+		}
+	}
+
+	hosts := []client.StaticDhcpHost{}
+	for _, host := range c.hosts {
+		if network != nil && !network.Contains(net.ParseIP(host.IPAddress)) {
+			continue
+		}
+		if matched, _ := path.Match(filter.HostNamePattern, host.HostName); filter.HostNamePattern != "" && !matched {
+			continue
+		}
+		if !strings.HasPrefix(host.MacAddress, strings.ToLower(filter.MacPrefix)) {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	slices.SortFunc(hosts, func(a, b client.StaticDhcpHost) int {
+		return strings.Compare(a.MacAddress, b.MacAddress)
+	})
+	return hosts, nil
+}
+
+func (c *memoryClient) BatchStaticDhcpHosts(ctx context.Context, changes []client.StaticDhcpHostChange) ([]client.StaticDhcpHostResult, error) {
+	results := make([]client.StaticDhcpHostResult, len(changes))
+	for i, change := range changes {
+		switch change.Operation {
+		case client.OperationCreate:
+			results[i].Host, results[i].Err = c.CreateStaticDhcpHost(ctx, change.Host)
+		case client.OperationUpdate:
+			results[i].Host, results[i].Err = c.UpdateStaticDhcpHost(ctx, change.Host)
+		case client.OperationDelete:
+			results[i].Host, results[i].Err = c.DeleteStaticDhcpHost(ctx, change.Host.MacAddress, change.Host.Revision)
+		}
+	}
+	return results, nil
+}
+
+func (c *memoryClient) Discover(ctx context.Context) (*client.ServerInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	if c.info == nil {
+		c.info = &client.ServerInfo{
+			APIVersion:     "1.0",
+			ManagerVersion: "0.6.0",
+			DnsmasqVersion: "2.90",
+			Features:       []client.Feature{client.FeatureStaticHosts, client.FeatureListStaticHosts, client.FeatureBatchStaticHosts},
+		}
+	}
+	return c.info, nil
+}
+
+func (c *memoryClient) ServerInfo() *client.ServerInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.info
+}
+
+func memoryAPIError(status int, method string, message string) client.APIError {
+	return client.APIError{
+		StatusCode: status,
+		Method:     method,
+		URL:        "memory:///api/v1/static/host",
+		Message:    message,
+	}
+}
@@ -2,20 +2,30 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"terraform-provider-dnsmasq/internal/client"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure dnsmasqProvider satisfies various provider interfaces.
-var _ provider.Provider = &dnsmasqProvider{}
+var (
+	_ provider.Provider                   = &dnsmasqProvider{}
+	_ provider.ProviderWithValidateConfig = &dnsmasqProvider{}
+)
 
 // dnsmasqProvider defines the provider implementation.
 type dnsmasqProvider struct {
@@ -27,8 +37,40 @@ type dnsmasqProvider struct {
 
 // dnsmasqProviderModel describes the provider data model.
 type dnsmasqProviderModel struct {
-	URL   types.String `tfsdk:"api_url"`
-	Token types.String `tfsdk:"api_token"`
+	URL          types.String `tfsdk:"api_url"`
+	StandbyURLs  types.List   `tfsdk:"standby_api_urls"`
+	ReplicaURLs  types.List   `tfsdk:"replica_api_urls"`
+	Token        types.String `tfsdk:"api_token"`
+	TokenFile    types.String `tfsdk:"api_token_file"`
+	TokenCommand types.List   `tfsdk:"api_token_command"`
+	Username     types.String `tfsdk:"username"`
+	Password     types.String `tfsdk:"password"`
+	MaxRetries   types.Int64  `tfsdk:"max_retries"`
+	RetryWaitMin types.String `tfsdk:"retry_wait_min"`
+	RetryWaitMax types.String `tfsdk:"retry_wait_max"`
+
+	CACertPEM          types.String `tfsdk:"ca_cert_pem"`
+	CACertFile         types.String `tfsdk:"ca_cert_file"`
+	ClientCert         types.String `tfsdk:"client_cert"`
+	ClientKey          types.String `tfsdk:"client_key"`
+	TLSServerName      types.String `tfsdk:"tls_server_name"`
+	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+
+	RequestTimeout      types.String `tfsdk:"request_timeout"`
+	DialTimeout         types.String `tfsdk:"dial_timeout"`
+	TLSHandshakeTimeout types.String `tfsdk:"tls_handshake_timeout"`
+	HTTPProxy           types.String `tfsdk:"http_proxy"`
+	MaxIdleConns        types.Int64  `tfsdk:"max_idle_conns"`
+	MaxIdleConnsPerHost types.Int64  `tfsdk:"max_idle_conns_per_host"`
+	IdleConnTimeout     types.String `tfsdk:"idle_conn_timeout"`
+
+	MaxConcurrentRequests types.Int64   `tfsdk:"max_concurrent_requests"`
+	RequestsPerSecond     types.Float64 `tfsdk:"requests_per_second"`
+
+	BatchWindow types.String `tfsdk:"batch_window"`
+	ReadCache   types.Bool   `tfsdk:"read_cache"`
+
+	UserAgentSuffix types.String `tfsdk:"user_agent_suffix"`
 }
 
 func (p *dnsmasqProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -41,18 +83,176 @@ func (p *dnsmasqProvider) Schema(ctx context.Context, req provider.SchemaRequest
 		MarkdownDescription: "Use the dnsmasq provider to manage dnsmasq resources using the dnsmasq-manager API (see: https://github.com/gringolito/dnsmasq-manager).",
 		Attributes: map[string]schema.Attribute{
 			"api_url": schema.StringAttribute{
-				MarkdownDescription: "dnsmasq-manager API URL.",
+				MarkdownDescription: "dnsmasq-manager API URL, including the path the API is published under when behind a reverse proxy (e.g. `https://gw.example/dnsmasq/`). A Unix domain socket can be used with `unix:///run/dnsmasq-manager.sock`, optionally followed by an HTTP path prefix separated by a colon (e.g. `unix:///run/dnsmasq-manager.sock:/dnsmasq`).",
 				Required:            true,
 			},
+			"standby_api_urls": schema.ListAttribute{
+				MarkdownDescription: "Ordered list of standby dnsmasq-manager API URLs. When `api_url` fails with a connection error or a server error, the provider fails over to the first healthy standby and keeps using it for the rest of the run. " +
+					"Unix domain sockets are not supported. Can also be set with the `DMM_STANDBY_API_URLS` environment variable, as a comma separated list.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"replica_api_urls": schema.ListAttribute{
+				MarkdownDescription: "dnsmasq-manager API URLs of additional dnsmasq servers that must carry the same reservations as `api_url`. " +
+					"Every write is applied to `api_url`, then to each replica, bringing replicas that are out of sync in line with it, and every refresh reads from all of them, reporting reservations that differ between servers. " +
+					"The other settings, including credentials, apply to every replica. Can also be set with the `DMM_REPLICA_API_URLS` environment variable, as a comma separated list.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
 			"api_token": schema.StringAttribute{
-				MarkdownDescription: "dnsmasq-manager API JWT authentication token.",
+				MarkdownDescription: "dnsmasq-manager API JWT authentication token. Only one of `api_token`, `api_token_file`, `api_token_command` and `username` can be set.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"api_token_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a file holding the dnsmasq-manager API JWT authentication token. The file is read again whenever it changes, so rotated secrets are picked up during long runs. Can also be set with the `DMM_API_TOKEN_FILE` environment variable.",
+				Optional:            true,
+			},
+			"api_token_command": schema.ListAttribute{
+				MarkdownDescription: "Command, and its arguments, executed to obtain the dnsmasq-manager API JWT authentication token. " +
+					"The command must print a JSON object such as `{\"token\": \"...\", \"expiry\": \"2024-01-02T15:04:05Z\"}` to its standard output, the `expiry` being optional. " +
+					"The command is executed again shortly before the token expires. Can also be set with the `DMM_API_TOKEN_COMMAND` environment variable, as a space separated command line.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"username": schema.StringAttribute{
+				MarkdownDescription: "dnsmasq-manager username. When set, the provider logs in with `username` and `password` to obtain a JWT, refreshing it before it expires. Can also be set with the `DMM_USERNAME` environment variable.",
+				Optional:            true,
+			},
+			"password": schema.StringAttribute{
+				MarkdownDescription: "dnsmasq-manager password used together with `username`. Can also be set with the `DMM_PASSWORD` environment variable.",
 				Optional:            true,
 				Sensitive:           true,
 			},
+			"max_retries": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of times a request failing with a transient error (connection errors, 429, 502, 503 and 504) is retried. Requests that are not idempotent are only retried when the server did not process them. Defaults to `4`, set to `0` to disable retries.",
+				Optional:            true,
+			},
+			"retry_wait_min": schema.StringAttribute{
+				MarkdownDescription: "Minimum time to wait before retrying a failed request, as a duration string (e.g. `500ms`, `1s`). The wait grows exponentially with each attempt. Defaults to `1s`.",
+				Optional:            true,
+			},
+			"retry_wait_max": schema.StringAttribute{
+				MarkdownDescription: "Maximum time to wait before retrying a failed request, as a duration string (e.g. `30s`, `1m`). A `Retry-After` header sent by the server takes precedence, up to this maximum. Defaults to `30s`.",
+				Optional:            true,
+			},
+			"ca_cert_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded CA certificates trusted to verify the dnsmasq-manager server certificate, in addition to the system certificate pool. Conflicts with `ca_cert_file`. Can also be set with the `DMM_CA_CERT_PEM` environment variable.",
+				Optional:            true,
+			},
+			"ca_cert_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a file holding PEM encoded CA certificates trusted to verify the dnsmasq-manager server certificate, in addition to the system certificate pool. Conflicts with `ca_cert_pem`. Can also be set with the `DMM_CA_CERT_FILE` environment variable.",
+				Optional:            true,
+			},
+			"client_cert": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded client certificate presented to dnsmasq-manager for mutual TLS. Requires `client_key`. Can also be set with the `DMM_CLIENT_CERT` environment variable.",
+				Optional:            true,
+			},
+			"client_key": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded private key of the client certificate. Requires `client_cert`. Can also be set with the `DMM_CLIENT_KEY` environment variable.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"tls_server_name": schema.StringAttribute{
+				MarkdownDescription: "Server name used to verify the dnsmasq-manager certificate, when it differs from the `api_url` host. Can also be set with the `DMM_TLS_SERVER_NAME` environment variable.",
+				Optional:            true,
+			},
+			"insecure_skip_verify": schema.BoolAttribute{
+				MarkdownDescription: "Disable the verification of the dnsmasq-manager server certificate. This is insecure and should only be used for testing. Can also be set with the `DMM_INSECURE_SKIP_VERIFY` environment variable.",
+				Optional:            true,
+			},
+			"request_timeout": schema.StringAttribute{
+				MarkdownDescription: "Maximum time a single request to dnsmasq-manager may take, including reading the response, as a duration string (e.g. `30s`, `2m`). Each retry gets its own timeout. Defaults to `1m`.",
+				Optional:            true,
+			},
+			"dial_timeout": schema.StringAttribute{
+				MarkdownDescription: "Maximum time to establish a connection with dnsmasq-manager, as a duration string. Defaults to `30s`.",
+				Optional:            true,
+			},
+			"tls_handshake_timeout": schema.StringAttribute{
+				MarkdownDescription: "Maximum time to complete the TLS handshake with dnsmasq-manager, as a duration string. Defaults to `10s`.",
+				Optional:            true,
+			},
+			"http_proxy": schema.StringAttribute{
+				MarkdownDescription: "URL of the proxy used to reach dnsmasq-manager (e.g. `http://proxy.example:3128`). Hosts listed in the `NO_PROXY` environment variable are reached directly. " +
+					"When not set, the proxy is taken from the `HTTP_PROXY` and `HTTPS_PROXY` environment variables. Can also be set with the `DMM_HTTP_PROXY` environment variable.",
+				Optional: true,
+			},
+			"max_idle_conns": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of idle connections kept open for reuse. Defaults to `100`.",
+				Optional:            true,
+			},
+			"max_idle_conns_per_host": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of idle connections kept open for reuse per dnsmasq-manager host. Defaults to `2`.",
+				Optional:            true,
+			},
+			"idle_conn_timeout": schema.StringAttribute{
+				MarkdownDescription: "Maximum time an idle connection is kept open for reuse, as a duration string. Defaults to `90s`.",
+				Optional:            true,
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of requests in flight to dnsmasq-manager at any time, shared by all the resources and data sources of this provider instance. Unlimited by default.",
+				Optional:            true,
+			},
+			"requests_per_second": schema.Float64Attribute{
+				MarkdownDescription: "Maximum number of requests started per second against dnsmasq-manager, shared by all the resources and data sources of this provider instance. Unlimited by default.",
+				Optional:            true,
+			},
+			"batch_window": schema.StringAttribute{
+				MarkdownDescription: "When set, static DHCP host writes arriving within this window (e.g. `100ms`) are coalesced into a single batch request, so an apply of many reservations only makes dnsmasq-manager reload dnsmasq a handful of times. " +
+					"Requires a dnsmasq-manager supporting batch operations. Disabled by default.",
+				Optional: true,
+			},
+			"read_cache": schema.BoolAttribute{
+				MarkdownDescription: "When `true`, all the static DHCP hosts are fetched with a single list request on the first read, and later reads are served from memory for the rest of the run. " +
+					"Hosts written by the provider are read from the server again. Speeds up plans of large workspaces. Requires a dnsmasq-manager supporting host listing. Defaults to `false`.",
+				Optional: true,
+			},
+			"user_agent_suffix": schema.StringAttribute{
+				MarkdownDescription: "Text appended to the `User-Agent` header sent to dnsmasq-manager, e.g. to identify the pipeline running Terraform in the access logs. Can also be set with the `DMM_USER_AGENT_SUFFIX` environment variable.",
+				Optional:            true,
+			},
 		},
 	}
 }
 
+// credentialAttributes lists the ways to authenticate, in the order of
+// precedence applied by authOptionsFromModel.
+var credentialAttributes = []string{"api_token", "api_token_file", "api_token_command", "username"}
+
+// ValidateConfig rejects configurations setting more than one way to
+// authenticate, which would otherwise be resolved silently by precedence.
+func (p *dnsmasqProvider) ValidateConfig(ctx context.Context, req provider.ValidateConfigRequest, resp *provider.ValidateConfigResponse) {
+	var config dnsmasqProviderModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	set := map[string]bool{
+		"api_token":         !config.Token.IsNull(),
+		"api_token_file":    !config.TokenFile.IsNull(),
+		"api_token_command": !config.TokenCommand.IsNull(),
+		"username":          !config.Username.IsNull(),
+	}
+	used := ""
+	for _, attribute := range credentialAttributes {
+		if !set[attribute] {
+			continue
+		}
+		if used == "" {
+			used = attribute
+			continue
+		}
+
+		resp.Diagnostics.AddAttributeError(
+			path.Root(attribute),
+			"Conflicting dnsmasq-manager credentials",
+			fmt.Sprintf("`%s` cannot be combined with `%s`, which would be used instead. Set a single way to authenticate.", attribute, used),
+		)
+	}
+}
+
 func (p *dnsmasqProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
 	var config dnsmasqProviderModel
 	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
@@ -78,6 +278,27 @@ func (p *dnsmasqProvider) Configure(ctx context.Context, req provider.ConfigureR
 				"Either target apply the source of the value first, set the value statically in the configuration, or use the DMM_API_TOKEN environment variable.",
 		)
 	}
+	for _, credential := range []struct {
+		attribute string
+		name      string
+		env       string
+		unknown   bool
+	}{
+		{"api_token_file", "API token file", "DMM_API_TOKEN_FILE", config.TokenFile.IsUnknown()},
+		{"api_token_command", "API token command", "DMM_API_TOKEN_COMMAND", config.TokenCommand.IsUnknown()},
+		{"username", "username", "DMM_USERNAME", config.Username.IsUnknown()},
+		{"password", "password", "DMM_PASSWORD", config.Password.IsUnknown()},
+	} {
+		if !credential.unknown {
+			continue
+		}
+		resp.Diagnostics.AddAttributeError(
+			path.Root(credential.attribute),
+			"Unknown dnsmasq-manager "+credential.name,
+			fmt.Sprintf("The provider cannot create the dnsmasq client as there is an unknown configuration value for the dnsmasq-manager %s. ", credential.name)+
+				fmt.Sprintf("Either target apply the source of the value first, set the value statically in the configuration, or use the %s environment variable.", credential.env),
+		)
+	}
 
 	if resp.Diagnostics.HasError() {
 		return
@@ -106,16 +327,409 @@ func (p *dnsmasqProvider) Configure(ctx context.Context, req provider.ConfigureR
 				"Set the host value in the configuration or use the DMM_API_URL environment variable. "+
 				"If either is already set, ensure the value is not empty.",
 		)
+	} else if err := client.ValidateURL(url); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("api_url"),
+			"Invalid dnsmasq-manager API URL",
+			"The dnsmasq-manager API URL must be an absolute http or https URL, optionally including the path the API is published under "+
+				"(e.g. https://gw.example/dnsmasq/), or a Unix socket URL (e.g. unix:///run/dnsmasq-manager.sock).\n\n"+err.Error(),
+		)
+	}
+
+	maxRetries := int64(client.DefaultMaxRetries)
+	if !config.MaxRetries.IsNull() && !config.MaxRetries.IsUnknown() {
+		maxRetries = config.MaxRetries.ValueInt64()
+		if maxRetries < 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("max_retries"),
+				"Invalid maximum number of retries",
+				fmt.Sprintf("The maximum number of retries must not be negative, got: %d.", maxRetries),
+			)
+		}
+	}
+
+	retryWaitMin := parseDurationAttribute(config.RetryWaitMin, path.Root("retry_wait_min"), client.DefaultRetryWaitMin, &resp.Diagnostics)
+	retryWaitMax := parseDurationAttribute(config.RetryWaitMax, path.Root("retry_wait_max"), client.DefaultRetryWaitMax, &resp.Diagnostics)
+	if retryWaitMin > retryWaitMax {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("retry_wait_min"),
+			"Invalid retry wait interval",
+			fmt.Sprintf("The minimum retry wait (%s) must not be greater than the maximum retry wait (%s).", retryWaitMin, retryWaitMax),
+		)
+	}
+
+	tlsConfig := tlsConfigFromModel(config, &resp.Diagnostics)
+	transportConfig := transportConfigFromModel(config, &resp.Diagnostics)
+	batchWindow := parseDurationAttribute(config.BatchWindow, path.Root("batch_window"), 0, &resp.Diagnostics)
+	maxConcurrentRequests := positiveInt64Attribute(config.MaxConcurrentRequests, path.Root("max_concurrent_requests"), &resp.Diagnostics)
+	requestsPerSecond := config.RequestsPerSecond.ValueFloat64()
+	if requestsPerSecond < 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("requests_per_second"),
+			"Invalid number",
+			fmt.Sprintf("The value must not be negative (0 disables rate limiting), got: %g.", requestsPerSecond),
+		)
+	}
+	standbyUrls := urlListFromModel(ctx, config.StandbyURLs, path.Root("standby_api_urls"), "DMM_STANDBY_API_URLS", false, &resp.Diagnostics)
+	replicaUrls := urlListFromModel(ctx, config.ReplicaURLs, path.Root("replica_api_urls"), "DMM_REPLICA_API_URLS", true, &resp.Diagnostics)
+	authOptions := authOptionsFromModel(ctx, config, &resp.Diagnostics)
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
+	options := []client.Option{
+		client.WithRetry(int(maxRetries), retryWaitMin, retryWaitMax),
+		client.WithTLS(tlsConfig),
+		client.WithTransport(transportConfig),
+		client.WithRateLimit(int(maxConcurrentRequests), requestsPerSecond),
+		client.WithUserAgent(p.userAgent(req.TerraformVersion, stringValueOrEnv(config.UserAgentSuffix, "DMM_USER_AGENT_SUFFIX"))),
+	}
+	options = append(options, authOptions...)
+
+	// Every replica is reached with the same settings, only the primary
+	// endpoint fails over to the standby endpoints.
+	primaryOptions := slices.Clip(options)
+	if len(standbyUrls) > 0 {
+		primaryOptions = append(primaryOptions, client.WithFailover(standbyUrls...))
+	}
+
+	dnsmasq := configureEndpoint(ctx, url, token, primaryOptions, batchWindow, config.ReadCache.ValueBool(), &resp.Diagnostics)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	client := client.New(url, token)
+	if len(replicaUrls) > 0 {
+		replicas := []client.Replica{{Endpoint: url, Client: dnsmasq}}
+		for _, replicaUrl := range replicaUrls {
+			replica := configureEndpoint(ctx, replicaUrl, token, options, batchWindow, config.ReadCache.ValueBool(), &resp.Diagnostics)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+			replicas = append(replicas, client.Replica{Endpoint: replicaUrl, Client: replica})
+		}
+
+		dnsmasq = client.NewReplicatedClient(replicas)
+	}
+
+	resp.DataSourceData = dnsmasq
+	resp.ResourceData = dnsmasq
+}
+
+// userAgent returns the User-Agent header identifying the Terraform and
+// provider versions, followed by the suffix when set.
+func (p *dnsmasqProvider) userAgent(terraformVersion string, suffix string) string {
+	userAgent := fmt.Sprintf("Terraform/%s terraform-provider-dnsmasq/%s", terraformVersion, p.version)
+	if suffix != "" {
+		userAgent += " " + suffix
+	}
+	return userAgent
+}
+
+// configureEndpoint creates the client of a single dnsmasq-manager endpoint and
+// discovers its capabilities, wrapping it to batch writes or cache reads when
+// enabled.
+func configureEndpoint(ctx context.Context, url string, token string, options []client.Option, batchWindow time.Duration, readCache bool, diags *diag.Diagnostics) client.Client {
+	dnsmasq, err := client.New(url, token, options...)
+	if err != nil {
+		diags.AddError(
+			"Unable to create dnsmasq client",
+			"An unexpected error occurred when creating the dnsmasq client. "+
+				"If the error is not clear, please contact the provider developers.\n\n"+
+				"dnsmasq client error: "+err.Error(),
+		)
+		return nil
+	}
+
+	info, err := dnsmasq.Discover(ctx)
+	if err != nil {
+		diags.AddError(
+			"Unable to discover dnsmasq-manager capabilities",
+			fmt.Sprintf("The provider could not query the version and features of the dnsmasq-manager at %s. ", url)+
+				"Ensure the API URL and credentials are correct and the server is reachable.\n\n"+
+				"dnsmasq client error: "+err.Error(),
+		)
+		return nil
+	}
+
+	tflog.Info(ctx, "Discovered dnsmasq-manager capabilities", map[string]interface{}{
+		"api_url":         url,
+		"api_version":     info.APIVersion,
+		"manager_version": info.ManagerVersion,
+		"dnsmasq_version": info.DnsmasqVersion,
+		"features":        fmt.Sprint(info.Features),
+	})
+
+	if batchWindow > 0 {
+		diags.Append(checkServerFeature(dnsmasq, client.FeatureBatchStaticHosts, "batch_window")...)
+		if diags.HasError() {
+			return nil
+		}
+
+		dnsmasq = client.NewBatchingClient(dnsmasq, batchWindow)
+	}
+
+	if readCache {
+		diags.Append(checkServerFeature(dnsmasq, client.FeatureListStaticHosts, "read_cache")...)
+		if diags.HasError() {
+			return nil
+		}
+
+		dnsmasq = client.NewCachingClient(dnsmasq)
+	}
+
+	return dnsmasq
+}
+
+// checkServerFeature returns an error diagnostic when the dnsmasq-manager
+// server does not support a feature required by the named resource or data
+// source.
+func checkServerFeature(dnsmasq client.Client, feature client.Feature, name string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	info := dnsmasq.ServerInfo()
+	if info == nil || info.Supports(feature) {
+		return diags
+	}
+
+	diags.AddError(
+		"Unsupported dnsmasq-manager version",
+		fmt.Sprintf("%s requires dnsmasq-manager >= %s (feature %q), but the server reports version %s (API version %s). "+
+			"Upgrade dnsmasq-manager to use it.",
+			name, feature.MinimumVersion(), feature, info.ManagerVersion, info.APIVersion),
+	)
+
+	return diags
+}
+
+// authOptionsFromModel returns the client options replacing the static API
+// token with another way to authenticate. Credentials set in the
+// configuration take precedence over the ones set through environment
+// variables. A single way to authenticate can be set through environment
+// variables, conflicts in the configuration are reported by ValidateConfig.
+func authOptionsFromModel(ctx context.Context, config dnsmasqProviderModel, diags *diag.Diagnostics) []client.Option {
+	switch {
+	case !config.Token.IsNull():
+		return nil
+	case !config.TokenFile.IsNull():
+		return []client.Option{client.WithTokenFile(config.TokenFile.ValueString())}
+	case !config.TokenCommand.IsNull():
+		var command []string
+		diags.Append(config.TokenCommand.ElementsAs(ctx, &command, false)...)
+		if len(command) == 0 {
+			diags.AddAttributeError(
+				path.Root("api_token_command"),
+				"Empty dnsmasq-manager API token command",
+				"The API token command must include at least the executable to run.",
+			)
+			return nil
+		}
+		return []client.Option{client.WithTokenCommand(command)}
+	case !config.Username.IsNull():
+		return loginOptions(config.Username.ValueString(), config, diags)
+	}
+
+	var envCredentials []string
+	for _, name := range []string{"DMM_API_TOKEN", "DMM_API_TOKEN_FILE", "DMM_API_TOKEN_COMMAND", "DMM_USERNAME"} {
+		if os.Getenv(name) != "" {
+			envCredentials = append(envCredentials, name)
+		}
+	}
+	if len(envCredentials) > 1 {
+		diags.AddError(
+			"Conflicting dnsmasq-manager credentials",
+			fmt.Sprintf("Several ways to authenticate are set through environment variables: %s. Set a single way to authenticate.",
+				strings.Join(envCredentials, ", ")),
+		)
+		return nil
+	}
+
+	switch {
+	case os.Getenv("DMM_API_TOKEN") != "":
+		return nil
+	case os.Getenv("DMM_API_TOKEN_FILE") != "":
+		return []client.Option{client.WithTokenFile(os.Getenv("DMM_API_TOKEN_FILE"))}
+	case os.Getenv("DMM_API_TOKEN_COMMAND") != "":
+		return []client.Option{client.WithTokenCommand(strings.Fields(os.Getenv("DMM_API_TOKEN_COMMAND")))}
+	case os.Getenv("DMM_USERNAME") != "":
+		return loginOptions(os.Getenv("DMM_USERNAME"), config, diags)
+	}
+
+	return nil
+}
+
+func loginOptions(username string, config dnsmasqProviderModel, diags *diag.Diagnostics) []client.Option {
+	password := stringValueOrEnv(config.Password, "DMM_PASSWORD")
+	if password == "" {
+		diags.AddAttributeError(
+			path.Root("password"),
+			"Missing dnsmasq-manager password",
+			"The provider cannot log in to dnsmasq-manager as there is a missing or empty value for the password. "+
+				"Set the password value in the configuration or use the DMM_PASSWORD environment variable.",
+		)
+		return nil
+	}
+
+	return []client.Option{client.WithLogin(username, password)}
+}
+
+// urlListFromModel returns a list of API URLs from the configuration,
+// defaulting to the comma separated list held by the env environment variable.
+// Unix socket URLs are rejected unless allowUnixSocket is set.
+func urlListFromModel(ctx context.Context, value types.List, attributePath path.Path, env string, allowUnixSocket bool, diags *diag.Diagnostics) []string {
+	var urls []string
+	if value.IsNull() {
+		for _, url := range strings.Split(os.Getenv(env), ",") {
+			if url = strings.TrimSpace(url); url != "" {
+				urls = append(urls, url)
+			}
+		}
+	} else {
+		diags.Append(value.ElementsAs(ctx, &urls, false)...)
+	}
+
+	for i, url := range urls {
+		if err := client.ValidateURL(url); err != nil {
+			diags.AddAttributeError(
+				attributePath.AtListIndex(i),
+				"Invalid dnsmasq-manager API URL",
+				"The dnsmasq-manager API URLs must be absolute http or https URLs, optionally including the path the API is published under "+
+					"(e.g. https://gw.example/dnsmasq/).\n\n"+err.Error(),
+			)
+			continue
+		}
+		if !allowUnixSocket && client.IsUnixSocketURL(url) {
+			diags.AddAttributeError(
+				attributePath.AtListIndex(i),
+				"Invalid dnsmasq-manager API URL",
+				fmt.Sprintf("Unix socket URLs cannot be used in %s, the endpoints must be absolute http or https URLs, got: %s.", attributePath, url),
+			)
+		}
+	}
+
+	return urls
+}
+
+// tlsConfigFromModel builds the client TLS settings from the provider
+// configuration, defaulting each setting to its environment variable.
+func tlsConfigFromModel(config dnsmasqProviderModel, diags *diag.Diagnostics) client.TLSConfig {
+	caCertPEM := stringValueOrEnv(config.CACertPEM, "DMM_CA_CERT_PEM")
+	caCertFile := stringValueOrEnv(config.CACertFile, "DMM_CA_CERT_FILE")
+	if caCertPEM != "" && caCertFile != "" {
+		diags.AddAttributeError(
+			path.Root("ca_cert_file"),
+			"Conflicting dnsmasq-manager CA certificate",
+			"Only one of ca_cert_pem (DMM_CA_CERT_PEM) and ca_cert_file (DMM_CA_CERT_FILE) can be set.",
+		)
+	}
+	if caCertFile != "" {
+		pem, err := os.ReadFile(caCertFile)
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("ca_cert_file"),
+				"Unable to read dnsmasq-manager CA certificate",
+				fmt.Sprintf("The provider cannot read the CA certificate file %q: %s", caCertFile, err),
+			)
+		}
+		caCertPEM = string(pem)
+	}
+
+	clientCert := stringValueOrEnv(config.ClientCert, "DMM_CLIENT_CERT")
+	clientKey := stringValueOrEnv(config.ClientKey, "DMM_CLIENT_KEY")
+	if (clientCert == "") != (clientKey == "") {
+		diags.AddAttributeError(
+			path.Root("client_cert"),
+			"Incomplete dnsmasq-manager client certificate",
+			"Both client_cert (DMM_CLIENT_CERT) and client_key (DMM_CLIENT_KEY) must be set to use mutual TLS.",
+		)
+	}
+
+	insecureSkipVerify := false
+	if env := os.Getenv("DMM_INSECURE_SKIP_VERIFY"); env != "" {
+		value, err := strconv.ParseBool(env)
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("insecure_skip_verify"),
+				"Invalid DMM_INSECURE_SKIP_VERIFY environment variable",
+				fmt.Sprintf("The value %q is not a valid boolean.", env),
+			)
+		}
+		insecureSkipVerify = value
+	}
+	if !config.InsecureSkipVerify.IsNull() && !config.InsecureSkipVerify.IsUnknown() {
+		insecureSkipVerify = config.InsecureSkipVerify.ValueBool()
+	}
+
+	return client.TLSConfig{
+		CACertPEM:          []byte(caCertPEM),
+		ClientCertPEM:      []byte(clientCert),
+		ClientKeyPEM:       []byte(clientKey),
+		ServerName:         stringValueOrEnv(config.TLSServerName, "DMM_TLS_SERVER_NAME"),
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+}
+
+// transportConfigFromModel builds the client connection settings from the
+// provider configuration. Settings that are not configured are left zero so
+// the client keeps its defaults.
+func transportConfigFromModel(config dnsmasqProviderModel, diags *diag.Diagnostics) client.TransportConfig {
+	return client.TransportConfig{
+		RequestTimeout:      parseDurationAttribute(config.RequestTimeout, path.Root("request_timeout"), 0, diags),
+		DialTimeout:         parseDurationAttribute(config.DialTimeout, path.Root("dial_timeout"), 0, diags),
+		TLSHandshakeTimeout: parseDurationAttribute(config.TLSHandshakeTimeout, path.Root("tls_handshake_timeout"), 0, diags),
+		Proxy:               stringValueOrEnv(config.HTTPProxy, "DMM_HTTP_PROXY"),
+		MaxIdleConns:        int(positiveInt64Attribute(config.MaxIdleConns, path.Root("max_idle_conns"), diags)),
+		MaxIdleConnsPerHost: int(positiveInt64Attribute(config.MaxIdleConnsPerHost, path.Root("max_idle_conns_per_host"), diags)),
+		IdleConnTimeout:     parseDurationAttribute(config.IdleConnTimeout, path.Root("idle_conn_timeout"), 0, diags),
+	}
+}
+
+// positiveInt64Attribute returns the value of an optional number attribute,
+// or zero when it is not set.
+func positiveInt64Attribute(value types.Int64, attributePath path.Path, diags *diag.Diagnostics) int64 {
+	if value.IsNull() || value.IsUnknown() {
+		return 0
+	}
+
+	if value.ValueInt64() <= 0 {
+		diags.AddAttributeError(
+			attributePath,
+			"Invalid number",
+			fmt.Sprintf("The value must be greater than zero, got: %d.", value.ValueInt64()),
+		)
+		return 0
+	}
+
+	return value.ValueInt64()
+}
+
+// stringValueOrEnv returns the configured value of an optional string
+// attribute, defaulting to the given environment variable when not set.
+func stringValueOrEnv(value types.String, env string) string {
+	if value.IsNull() || value.IsUnknown() {
+		return os.Getenv(env)
+	}
+	return value.ValueString()
+}
+
+// parseDurationAttribute parses an optional duration string attribute,
+// returning defaultValue when the attribute is not set.
+func parseDurationAttribute(value types.String, attributePath path.Path, defaultValue time.Duration, diags *diag.Diagnostics) time.Duration {
+	if value.IsNull() || value.IsUnknown() {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value.ValueString())
+	if err != nil || duration < 0 {
+		diags.AddAttributeError(
+			attributePath,
+			"Invalid duration",
+			fmt.Sprintf("The value %q is not a valid non-negative duration (e.g. \"500ms\", \"30s\", \"1m\").", value.ValueString()),
+		)
+		return defaultValue
+	}
 
-	resp.DataSourceData = client
-	resp.ResourceData = client
+	return duration
 }
 
 func (p *dnsmasqProvider) Resources(ctx context.Context) []func() resource.Resource {
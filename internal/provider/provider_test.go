@@ -1,22 +1,63 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"strings"
+	"terraform-provider-dnsmasq/internal/client"
+	"terraform-provider-dnsmasq/internal/dnsmasqtest"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 )
 
-const apiUrl = "http://localhost:6904"
+var (
+	// apiUrl and apiToken locate the dnsmasq-manager used by the acceptance
+	// tests: the server at DMM_TEST_API_URL when set, an in-process fake
+	// otherwise.
+	apiUrl   string
+	apiToken string
 
-// providerConfig is a shared configuration to combine with the actual
-// test configuration so the Dnsmasq client is properly configured.
-var providerConfig = fmt.Sprintf(`
+	// providerConfig is a shared configuration to combine with the actual
+	// test configuration so the Dnsmasq client is properly configured.
+	providerConfig string
+)
+
+func TestMain(m *testing.M) {
+	apiUrl = os.Getenv("DMM_TEST_API_URL")
+	apiToken = os.Getenv("DMM_API_TOKEN")
+
+	var server *dnsmasqtest.Server
+	if apiUrl == "" {
+		server = dnsmasqtest.NewServer(dnsmasqtest.WithUser("terraform", "terraform"))
+		apiUrl = server.URL
+		apiToken = server.Token()
+	}
+
+	token := ""
+	if apiToken != "" {
+		token = fmt.Sprintf("api_token = %q", apiToken)
+	}
+	providerConfig = fmt.Sprintf(`
 provider "dnsmasq" {
   api_url = %q
+  %s
+}
+`, apiUrl, token)
+
+	code := m.Run()
+	if server != nil {
+		server.Close()
+	}
+	os.Exit(code)
 }
-`, apiUrl)
 
 // testAccProtoV6ProviderFactories are used to instantiate a provider during
 // acceptance testing. The factory function will be invoked for every Terraform
@@ -31,3 +72,279 @@ func testAccPreCheck(t *testing.T) {
 	// about the appropriate environment variables being set are common to see in a pre-check
 	// function.
 }
+
+// testAccClient returns a client talking to the dnsmasq-manager used by the
+// acceptance tests, to set up and inspect objects outside of Terraform.
+func testAccClient() (client.Client, error) {
+	return client.New(apiUrl, apiToken)
+}
+
+func TestProviderUserAgent(t *testing.T) {
+	tests := map[string]struct {
+		suffix          string
+		expectUserAgent string
+	}{
+		"without suffix": {
+			expectUserAgent: "Terraform/1.9.0 terraform-provider-dnsmasq/test",
+		},
+		"with suffix": {
+			suffix:          "pipeline/network-sites",
+			expectUserAgent: "Terraform/1.9.0 terraform-provider-dnsmasq/test pipeline/network-sites",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := &dnsmasqProvider{version: "test"}
+			if userAgent := p.userAgent("1.9.0", test.suffix); userAgent != test.expectUserAgent {
+				t.Errorf("expected User-Agent %q, got %q", test.expectUserAgent, userAgent)
+			}
+		})
+	}
+}
+
+func TestProviderValidateConfig(t *testing.T) {
+	tests := map[string]struct {
+		config          map[string]tftypes.Value
+		expectConflicts []string
+	}{
+		"no credentials": {},
+		"api token": {
+			config: map[string]tftypes.Value{"api_token": tftypes.NewValue(tftypes.String, "token")},
+		},
+		"username and password": {
+			config: map[string]tftypes.Value{
+				"username": tftypes.NewValue(tftypes.String, "terraform"),
+				"password": tftypes.NewValue(tftypes.String, "secret"),
+			},
+		},
+		"api token and api token file": {
+			config: map[string]tftypes.Value{
+				"api_token":      tftypes.NewValue(tftypes.String, "token"),
+				"api_token_file": tftypes.NewValue(tftypes.String, "/run/secrets/dmm"),
+			},
+			expectConflicts: []string{"api_token_file"},
+		},
+		"every credential": {
+			config: map[string]tftypes.Value{
+				"api_token":         tftypes.NewValue(tftypes.String, "token"),
+				"api_token_file":    tftypes.NewValue(tftypes.String, "/run/secrets/dmm"),
+				"api_token_command": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "dmm-token")}),
+				"username":          tftypes.NewValue(tftypes.String, "terraform"),
+			},
+			expectConflicts: []string{"api_token_file", "api_token_command", "username"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := &dnsmasqProvider{version: "test"}
+			resp := provider.ValidateConfigResponse{}
+			p.ValidateConfig(context.Background(), provider.ValidateConfigRequest{Config: testProviderConfig(p, test.config)}, &resp)
+
+			if len(resp.Diagnostics) != len(test.expectConflicts) {
+				t.Fatalf("expected %d diagnostics, got: %v", len(test.expectConflicts), resp.Diagnostics)
+			}
+			for i, attribute := range test.expectConflicts {
+				d, ok := resp.Diagnostics[i].(diag.DiagnosticWithPath)
+				if !ok || !d.Path().Equal(path.Root(attribute)) || d.Summary() != "Conflicting dnsmasq-manager credentials" {
+					t.Errorf("expected a conflict on %s, got: %v", attribute, resp.Diagnostics[i])
+				}
+			}
+		})
+	}
+}
+
+// testProviderConfig returns the provider configuration holding the given
+// attribute values, every other attribute being null.
+func testProviderConfig(p *dnsmasqProvider, config map[string]tftypes.Value) tfsdk.Config {
+	schemaResp := provider.SchemaResponse{}
+	p.Schema(context.Background(), provider.SchemaRequest{}, &schemaResp)
+
+	objectType := schemaResp.Schema.Type().TerraformType(context.Background()).(tftypes.Object)
+	values := map[string]tftypes.Value{}
+	for attribute, attributeType := range objectType.AttributeTypes {
+		values[attribute] = tftypes.NewValue(attributeType, nil)
+	}
+	for attribute, value := range config {
+		values[attribute] = value
+	}
+
+	return tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, values)}
+}
+
+func TestProviderConfigure(t *testing.T) {
+	tests := map[string]struct {
+		server       []dnsmasqtest.Option
+		faults       func(server *dnsmasqtest.Server)
+		config       map[string]tftypes.Value
+		env          map[string]string
+		expectErrors []string
+		expectPath   path.Path
+		expectDetail string
+	}{
+		"fake dnsmasq-manager": {},
+		"invalid api url": {
+			config: map[string]tftypes.Value{
+				"api_url": tftypes.NewValue(tftypes.String, "ftp://localhost:6904"),
+			},
+			expectErrors: []string{"Invalid dnsmasq-manager API URL"},
+			expectPath:   path.Root("api_url"),
+			expectDetail: "the scheme must be http, https or unix",
+		},
+		"api url with a query string": {
+			config: map[string]tftypes.Value{
+				"api_url": tftypes.NewValue(tftypes.String, "http://localhost:6904/?token=secret"),
+			},
+			expectErrors: []string{"Invalid dnsmasq-manager API URL"},
+			expectPath:   path.Root("api_url"),
+			expectDetail: "query strings and fragments are not supported",
+		},
+		"invalid replica api url": {
+			config: map[string]tftypes.Value{
+				"replica_api_urls": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+					tftypes.NewValue(tftypes.String, "localhost:6905"),
+				}),
+			},
+			expectErrors: []string{"Invalid dnsmasq-manager API URL"},
+			expectPath:   path.Root("replica_api_urls").AtListIndex(0),
+			expectDetail: "invalid API URL",
+		},
+		"discovery failure": {
+			faults: func(server *dnsmasqtest.Server) {
+				server.InjectError("GET", "/api/v1/info", 500, 1)
+			},
+			config: map[string]tftypes.Value{
+				"max_retries": tftypes.NewValue(tftypes.Number, 0),
+			},
+			expectErrors: []string{"Unable to discover dnsmasq-manager capabilities"},
+			expectDetail: "Ensure the API URL and credentials are correct",
+		},
+		"replica discovery failure": {
+			config: map[string]tftypes.Value{
+				"replica_api_urls": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+					tftypes.NewValue(tftypes.String, "http://localhost:0"),
+				}),
+				"max_retries": tftypes.NewValue(tftypes.Number, 0),
+			},
+			expectErrors: []string{"Unable to discover dnsmasq-manager capabilities"},
+			expectDetail: "the dnsmasq-manager at http://localhost:0",
+		},
+		"batch window without batch support": {
+			server: []dnsmasqtest.Option{
+				dnsmasqtest.WithFeatures(dnsmasqtest.FeatureStaticHosts, dnsmasqtest.FeatureListStaticHosts),
+			},
+			config: map[string]tftypes.Value{
+				"batch_window": tftypes.NewValue(tftypes.String, "100ms"),
+			},
+			expectErrors: []string{"Unsupported dnsmasq-manager version"},
+			expectDetail: `batch_window requires dnsmasq-manager >= 0.6.0 (feature "batch_static_hosts")`,
+		},
+		"read cache without list support": {
+			server: []dnsmasqtest.Option{
+				dnsmasqtest.WithFeatures(dnsmasqtest.FeatureStaticHosts),
+			},
+			config: map[string]tftypes.Value{
+				"read_cache": tftypes.NewValue(tftypes.Bool, true),
+			},
+			expectErrors: []string{"Unsupported dnsmasq-manager version"},
+			expectDetail: `read_cache requires dnsmasq-manager >= 0.5.0 (feature "list_static_hosts")`,
+		},
+		"batch window and read cache": {
+			config: map[string]tftypes.Value{
+				"batch_window": tftypes.NewValue(tftypes.String, "100ms"),
+				"read_cache":   tftypes.NewValue(tftypes.Bool, true),
+			},
+		},
+		"unknown api token file": {
+			config: map[string]tftypes.Value{
+				"api_token_file": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			},
+			expectErrors: []string{"Unknown dnsmasq-manager API token file"},
+			expectPath:   path.Root("api_token_file"),
+			expectDetail: "DMM_API_TOKEN_FILE",
+		},
+		"unknown api token command": {
+			config: map[string]tftypes.Value{
+				"api_token_command": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, tftypes.UnknownValue),
+			},
+			expectErrors: []string{"Unknown dnsmasq-manager API token command"},
+			expectPath:   path.Root("api_token_command"),
+			expectDetail: "DMM_API_TOKEN_COMMAND",
+		},
+		"unknown username": {
+			config: map[string]tftypes.Value{
+				"username": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+				"password": tftypes.NewValue(tftypes.String, "secret"),
+			},
+			expectErrors: []string{"Unknown dnsmasq-manager username"},
+			expectPath:   path.Root("username"),
+			expectDetail: "DMM_USERNAME",
+		},
+		"unix socket standby url": {
+			config: map[string]tftypes.Value{
+				"standby_api_urls": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+					tftypes.NewValue(tftypes.String, "unix:///run/dnsmasq-manager.sock"),
+				}),
+			},
+			expectErrors: []string{"Invalid dnsmasq-manager API URL"},
+			expectPath:   path.Root("standby_api_urls").AtListIndex(0),
+			expectDetail: "Unix socket URLs cannot be used in standby_api_urls",
+		},
+		"unix socket standby url from the environment": {
+			env: map[string]string{
+				"DMM_STANDBY_API_URLS": "http://localhost:6905,unix:///run/dnsmasq-manager.sock",
+			},
+			expectErrors: []string{"Invalid dnsmasq-manager API URL"},
+			expectPath:   path.Root("standby_api_urls").AtListIndex(1),
+			expectDetail: "Unix socket URLs cannot be used in standby_api_urls",
+		},
+		"conflicting environment credentials": {
+			env: map[string]string{
+				"DMM_API_TOKEN_FILE": "/run/secrets/dmm",
+				"DMM_USERNAME":       "terraform",
+				"DMM_PASSWORD":       "secret",
+			},
+			expectErrors: []string{"Conflicting dnsmasq-manager credentials"},
+			expectDetail: "DMM_API_TOKEN_FILE, DMM_USERNAME",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, variable := range os.Environ() {
+				if env, _, _ := strings.Cut(variable, "="); strings.HasPrefix(env, "DMM_") {
+					t.Setenv(env, "")
+				}
+			}
+			for env, value := range test.env {
+				t.Setenv(env, value)
+			}
+
+			server := dnsmasqtest.NewServer(test.server...)
+			defer server.Close()
+			if test.faults != nil {
+				test.faults(server)
+			}
+
+			config := map[string]tftypes.Value{"api_url": tftypes.NewValue(tftypes.String, server.URL)}
+			for attribute, value := range test.config {
+				config[attribute] = value
+			}
+
+			p := &dnsmasqProvider{version: "test"}
+			resp := provider.ConfigureResponse{}
+			p.Configure(context.Background(), provider.ConfigureRequest{Config: testProviderConfig(p, config), TerraformVersion: "1.9.0"}, &resp)
+
+			checkDiagnostics(t, resp.Diagnostics, test.expectErrors, nil, test.expectDetail)
+			for _, d := range resp.Diagnostics.Errors() {
+				if d, ok := d.(diag.DiagnosticWithPath); ok && !d.Path().Equal(test.expectPath) {
+					t.Errorf("expected an error on %s, got one on %s", test.expectPath, d.Path())
+				}
+			}
+			if len(test.expectErrors) == 0 && resp.ResourceData == nil {
+				t.Error("expected a configured client")
+			}
+		})
+	}
+}